@@ -9,7 +9,9 @@ import (
 	"syscall"
 	"time"
 
+	"reading-list-api/internal/logging"
 	"reading-list-api/internal/server"
+	"reading-list-api/internal/tracing"
 )
 
 func gracefulShutdown(apiServer *http.Server, done chan bool) {
@@ -37,6 +39,12 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 }
 
 func main() {
+	logging.Setup()
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatalf("error setting up tracing: %v", err)
+	}
 
 	server := server.NewServer()
 
@@ -46,12 +54,19 @@ func main() {
 	// Run graceful shutdown in a separate goroutine
 	go gracefulShutdown(server, done)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
 
 	// Wait for the graceful shutdown to complete
 	<-done
+
+	// Flush any buffered spans before exiting
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("error shutting down tracing: %v", err)
+	}
 	log.Println("Graceful shutdown complete.")
 }
@@ -0,0 +1,78 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultBaseURL = "https://web.archive.org"
+const defaultTimeout = 2 * time.Minute
+
+// Client submits pages to the Wayback Machine's Save Page Now service.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+type ClientConfig struct {
+	BaseURL string
+
+	// Optional. If set, used only when HTTPClient is nil.
+	Timeout time.Duration
+
+	HTTPClient *http.Client
+}
+
+func NewClient(cfg ClientConfig) *Client {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		hc = &http.Client{Timeout: timeout}
+	}
+
+	return &Client{
+		baseURL: baseURL,
+		http:    hc,
+	}
+}
+
+// Save asks the archive to capture link and returns the URL of the snapshot.
+func (c *Client) Save(ctx context.Context, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/save/"+link, nil)
+	if err != nil {
+		return "", fmt.Errorf("archive save: create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("archive save: request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("archive save: status=%d", resp.StatusCode)
+	}
+
+	// The snapshot location is reported in Content-Location, or we end up on
+	// it after following redirects.
+	if loc := resp.Header.Get("Content-Location"); strings.HasPrefix(loc, "/web/") {
+		return c.baseURL + loc, nil
+	}
+	if final := resp.Request.URL; strings.HasPrefix(final.Path, "/web/") {
+		return final.String(), nil
+	}
+	return "", fmt.Errorf("archive save: no snapshot location in response")
+}
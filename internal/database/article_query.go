@@ -1,17 +1,121 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"reading-list-api/internal/types"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
 )
 
-func (s *service) GetAllArticles() (*[]types.Article, error) {
+// ErrArticleNotFound is returned when no article matches the requested id.
+var ErrArticleNotFound = errors.New("article not found")
+
+// ErrArticleExists is returned when an insert collides with a stored link.
+var ErrArticleExists = errors.New("article exists in db")
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// SortColumns maps the sort keys accepted by listings to their columns.
+var SortColumns = map[string]string{
+	"dateRead":      "date_read",
+	"createdAt":     "created_at",
+	"datePublished": "date_published",
+	"title":         "title collate nocase",
+	"id":            "id",
+}
+
+// ArticleOrder sorts listings after pinned articles and their manual sort
+// order. The zero value sorts by date read, newest first.
+type ArticleOrder struct {
+	// Sort is a key of SortColumns; empty means dateRead.
+	Sort string
+	// Ascending flips the default newest-first direction.
+	Ascending bool
+}
+
+// ParseArticleOrder validates a sort key and an asc/desc direction, either
+// of which may be empty to keep the default.
+func ParseArticleOrder(sort string, order string) (ArticleOrder, error) {
+	if sort != "" {
+		if _, ok := SortColumns[sort]; !ok {
+			return ArticleOrder{}, fmt.Errorf("invalid sort %q, must be one of dateRead, createdAt, datePublished, title, id", sort)
+		}
+	}
+	switch order {
+	case "", "desc":
+		return ArticleOrder{Sort: sort}, nil
+	case "asc":
+		return ArticleOrder{Sort: sort, Ascending: true}, nil
+	}
+	return ArticleOrder{}, fmt.Errorf("invalid order %q, must be asc or desc", order)
+}
+
+// orderBy builds the ORDER BY clause for article listings. id is always the
+// last key, so rows that tie on the sort column keep the same order from one
+// page to the next; every paged article query should go through it.
+func (o ArticleOrder) orderBy() string {
+	column, ok := SortColumns[o.Sort]
+	if !ok {
+		column = SortColumns["dateRead"]
+	}
+	direction := "desc"
+	if o.Ascending {
+		direction = "asc"
+	}
+	orderBy := fmt.Sprintf("order by pinned desc, sort_order asc, %s %s", column, direction)
+	if column != "id" {
+		orderBy += ", id " + direction
+	}
+	return orderBy
+}
+
+// listColumns are the article columns listings read: all but the stored
+// content and embedding, which can be large and are only needed one article
+// at a time.
+var listColumns = []string{
+	"id", "title", "author", "summary", "date_read", "date_published", "link", "original_link",
+	"img_path", "type", "pinned", "sort_order", "archive_url", "link_status", "last_checked",
+	"status", "created_at", "completed_at", "paper_id", "extraction_status", "extraction_error",
+	"extraction_attempts", "extraction_max_attempts", "extractor", "type_uncertain",
+	"author_guessed", "title_guessed", "site_name", "progress", "suggested_tags", "rating", "notes", "extracted",
+}
+
+// selectList is the column list of a listing, qualified with table when it
+// is not empty.
+func selectList(table string) string {
+	if table == "" {
+		return strings.Join(listColumns, ", ")
+	}
+	return table + "." + strings.Join(listColumns, ", "+table+".")
+}
+
+// listedCondition is the condition for an article to show up in listings,
+// counts and stats: its extraction is complete, or it has completed before
+// and is being extracted again. table qualifies the columns when it is not
+// empty.
+func listedCondition(table string) string {
+	if table != "" {
+		table += "."
+	}
+	return fmt.Sprintf("(%[1]sextraction_status = 'complete' or %[1]sextracted = 1)", table)
+}
+
+func (s *service) GetAllArticles(order ArticleOrder) (*[]types.Article, error) {
 	articles := make([]types.Article, 0)
-	query := `
-		select * from articles order by date_read desc, id desc;
-	`
+	query := fmt.Sprintf(`
+		select %s from articles where %s
+		%s;
+	`, selectList(""), listedCondition(""), order.orderBy())
 	err := s.db.Select(&articles, query)
 	if err != nil {
 		log.Println("error querying articles", err)
@@ -20,16 +124,132 @@ func (s *service) GetAllArticles() (*[]types.Article, error) {
 	return &articles, nil
 }
 
-func (s *service) GetArticlePage(offset int, limit int) (*[]types.Article, error) {
+// eachArticleBatch is how many articles EachArticle reads per query, a
+// variable so tests can page through a small library.
+var eachArticleBatch = 500
+
+// EachArticle calls fn with every article whose extraction is complete, in
+// order, so callers can stream a library of any size. It reads the articles
+// in keyset batches rather than through one long query, so a slow reader
+// doesn't hold a read transaction open for the whole stream. It stops at the
+// first error fn returns.
+func (s *service) EachArticle(ctx context.Context, order ArticleOrder, fn func(*types.Article) error) error {
+	filter := ArticleFilter{Order: order}
+	var after *ArticleCursor
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		articles, err := s.GetArticlePageAfterCursor(filter, after, eachArticleBatch)
+		if err != nil {
+			return fmt.Errorf("error querying articles: %v", err)
+		}
+		for i := range *articles {
+			if err := fn(&(*articles)[i]); err != nil {
+				return err
+			}
+		}
+		if len(*articles) < eachArticleBatch {
+			return nil
+		}
+		cursor := CursorAfter(&(*articles)[len(*articles)-1], order)
+		after = &cursor
+	}
+}
+
+// ArticleFilter narrows article listings. The zero value lists every article
+// whose extraction is complete, including ones being re-extracted.
+type ArticleFilter struct {
+	// Status is a reading status: unread, reading or read.
+	Status string
+	// ExtractionStatus keeps only articles in this extraction state. When
+	// empty, complete articles and ones being re-extracted are kept.
+	ExtractionStatus string
+	// AnyExtraction keeps articles whatever their extraction status,
+	// overriding ExtractionStatus.
+	AnyExtraction bool
+	// MaxID, when set, hides articles added after a listing snapshot.
+	MaxID int
+	// Site matches a site name or the domain of the link, without www.
+	Site string
+	// InProgress keeps articles that are partly read, progress 1-99.
+	InProgress bool
+	// Types keeps articles of any of these types; empty keeps every type.
+	Types []int
+	// Author keeps articles whose author contains it, ignoring case.
+	Author string
+	// Tags keeps articles that have every one of these tags, lowercase.
+	Tags []string
+	// Order sorts the page; it does not affect counts.
+	Order ArticleOrder
+}
+
+// likeEscaper makes user input match literally inside a like pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (f ArticleFilter) where() (string, []any) {
+	extraction := f.ExtractionStatus
+	clauses := []string{"extraction_status = ?"}
+	args := []any{extraction}
+	switch {
+	case f.AnyExtraction:
+		clauses, args = []string{"1"}, nil
+	case f.ExtractionStatus == "":
+		clauses, args = []string{listedCondition("")}, nil
+	}
+	if f.Status != "" {
+		clauses = append(clauses, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.MaxID > 0 {
+		clauses = append(clauses, "id <= ?")
+		args = append(args, f.MaxID)
+	}
+	if f.Site != "" {
+		clauses = append(clauses, `(site_name = ? collate nocase or link like ? escape '\' or link like ? escape '\'
+			or link like ? escape '\' or link like ? escape '\')`)
+		site := likeEscaper.Replace(f.Site)
+		args = append(args, f.Site, "http://"+site+"/%", "https://"+site+"/%", "http://www."+site+"/%", "https://www."+site+"/%")
+	}
+	if f.InProgress {
+		clauses = append(clauses, "progress between 1 and 99")
+	}
+	if f.Author != "" {
+		clauses = append(clauses, `lower(author) like lower(?) escape '\'`)
+		args = append(args, "%"+likeEscaper.Replace(f.Author)+"%")
+	}
+	if len(f.Tags) > 0 {
+		clauses = append(clauses, `id in (
+			select at.article_id from article_tags at join tags t on t.id = at.tag_id
+			where t.name in (?`+strings.Repeat(", ?", len(f.Tags)-1)+`)
+			group by at.article_id having count(*) = ?
+		)`)
+		for _, tag := range f.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(f.Tags))
+	}
+	if len(f.Types) > 0 {
+		clauses = append(clauses, "type in (?"+strings.Repeat(", ?", len(f.Types)-1)+")")
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	return "where " + strings.Join(clauses, " and "), args
+}
+
+func (s *service) GetArticlePage(filter ArticleFilter, offset int, limit int) (*[]types.Article, error) {
 	articles := make([]types.Article, 0)
-	query := `
-		select * from articles
-		order by date_read desc, id desc
+	where, args := filter.where()
+	query := fmt.Sprintf(`
+		select %s from articles
+		%s
+		%s
 		limit ?
 		offset ?;
-	`
+	`, selectList(""), where, filter.Order.orderBy())
 
-	err := s.db.Select(&articles, query, limit, offset)
+	err := s.db.Select(&articles, query, append(args, limit, offset)...)
 	if err != nil {
 		log.Println("error querying articles", err)
 		return nil, err
@@ -37,12 +257,13 @@ func (s *service) GetArticlePage(offset int, limit int) (*[]types.Article, error
 	return &articles, nil
 }
 
-func (s *service) GetArticleCount() (int, error) {
+func (s *service) GetArticleCount(filter ArticleFilter) (int, error) {
 	var articleCount int
-	query := `
-		select count(*) from articles;
-	`
-	err := s.db.QueryRow(query).Scan(&articleCount)
+	where, args := filter.where()
+	query := fmt.Sprintf(`
+		select count(*) from articles %s;
+	`, where)
+	err := s.db.QueryRow(query, args...).Scan(&articleCount)
 	if err != nil {
 		log.Println("error counting articles", err)
 		return 0, err
@@ -50,9 +271,24 @@ func (s *service) GetArticleCount() (int, error) {
 	return articleCount, nil
 }
 
+// GetMaxArticleID returns the newest article id, 0 for an empty library.
+func (s *service) GetMaxArticleID() (int, error) {
+	var maxID int
+	err := s.db.QueryRow(`select coalesce(max(id), 0) from articles;`).Scan(&maxID)
+	if err != nil {
+		return 0, err
+	}
+	return maxID, nil
+}
+
+// ArticleExists reports whether link is saved, as an article's link or as
+// one of its alternate links.
 func (s *service) ArticleExists(link string) (bool, error) {
 	article := types.Article{}
-	query := `select * from articles where link = $1;`
+	query := `
+		select * from articles
+		where link = $1 or id in (select article_id from article_links where link = $1);
+	`
 	err := s.db.Get(&article, query, link)
 	if err == sql.ErrNoRows {
 		return false, nil
@@ -63,7 +299,230 @@ func (s *service) ArticleExists(link string) (bool, error) {
 	return true, nil
 }
 
+const insertArticleQuery = `
+	insert into articles (
+		title,
+		author,
+		summary,
+		date_read,
+		date_published,
+		link,
+		original_link,
+		type,
+		status,
+		created_at,
+		completed_at,
+		paper_id,
+		extraction_status,
+		extractor,
+		type_uncertain,
+		author_guessed,
+		title_guessed,
+		extraction_max_attempts,
+		site_name,
+		suggested_tags,
+		content,
+		img_path,
+		rating,
+		notes,
+		extracted
+	) values(
+		:title,
+		:author,
+		:summary,
+		:date_read,
+		:date_published,
+		:link,
+		:original_link,
+		:type,
+		:status,
+		:created_at,
+		:completed_at,
+		:paper_id,
+		:extraction_status,
+		:extractor,
+		:type_uncertain,
+		:author_guessed,
+		:title_guessed,
+		:extraction_max_attempts,
+		:site_name,
+		:suggested_tags,
+		:content,
+		:img_path,
+		:rating,
+		:notes,
+		:extracted
+	);
+`
+
+// prepareInsert fills in the bookkeeping fields of a new article.
+func prepareInsert(article *types.Article) {
+	if article.Status == "" {
+		article.Status = types.StatusUnread
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if article.CreatedAt == "" {
+		article.CreatedAt = now
+	}
+	if article.Status == types.StatusRead && article.CompletedAt == "" {
+		article.CompletedAt = now
+	}
+	if article.ExtractionStatus == "" {
+		article.ExtractionStatus = types.ExtractionComplete
+	}
+	if article.ExtractionStatus == types.ExtractionComplete {
+		article.Extracted = true
+	}
+}
+
 func (s *service) InsertArticle(article *types.Article) error {
+	prepareInsert(article)
+
+	res, err := s.db.NamedExec(insertArticleQuery, &article)
+	if isUniqueViolation(err) {
+		// lost a race with a concurrent insert of the same link
+		return ErrArticleExists
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting into db: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error reading inserted id: %v", err)
+	}
+	article.ID = int(id)
+	return nil
+}
+
+func (s *service) GetArticleByID(id int) (*types.Article, error) {
+	article := types.Article{}
+	query := `select * from articles where id = ?;`
+	err := s.db.Get(&article, query, id)
+	if err == sql.ErrNoRows {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// GetEmbeddedArticles returns the complete articles that have a summary
+// embedding, with it.
+func (s *service) GetEmbeddedArticles() (*[]types.Article, error) {
+	articles := make([]types.Article, 0)
+	query := fmt.Sprintf(`
+		select %s, embedding from articles
+		where %s and length(embedding) > 0
+		order by id;
+	`, selectList(""), listedCondition(""))
+	err := s.db.Select(&articles, query)
+	if err != nil {
+		log.Println("error querying embedded articles", err)
+		return nil, err
+	}
+	return &articles, nil
+}
+
+// GetArticlesByIDs returns the articles with the given ids, in no
+// particular order. Ids with no article are left out.
+func (s *service) GetArticlesByIDs(ids []int) (*[]types.Article, error) {
+	articles := make([]types.Article, 0, len(ids))
+	if len(ids) == 0 {
+		return &articles, nil
+	}
+	query, args, err := sqlx.In(fmt.Sprintf(`select %s from articles where id in (?);`, selectList("")), ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&articles, query, args...); err != nil {
+		return nil, fmt.Errorf("error querying articles by id: %v", err)
+	}
+	return &articles, nil
+}
+
+// TogglePinned flips the pinned flag of an article. When sortOrder is non-nil
+// it also replaces the manual sort order used among pinned articles.
+func (s *service) TogglePinned(id int, sortOrder *int) error {
+	query := `
+		update articles
+		set pinned = not pinned,
+			sort_order = coalesce(?, sort_order)
+		where id = ?;
+	`
+	res, err := s.db.Exec(query, sortOrder, id)
+	if err != nil {
+		return fmt.Errorf("error updating pinned status: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+func (s *service) SetSummary(id int, summary string) error {
+	query := `update articles set summary = ? where id = ?;`
+	_, err := s.db.Exec(query, summary, id)
+	if err != nil {
+		return fmt.Errorf("error updating summary: %v", err)
+	}
+	return nil
+}
+
+func (s *service) SetArchiveURL(id int, archiveURL string) error {
+	query := `update articles set archive_url = ? where id = ?;`
+	_, err := s.db.Exec(query, archiveURL, id)
+	if err != nil {
+		return fmt.Errorf("error updating archive url: %v", err)
+	}
+	return nil
+}
+
+// SetLink rewrites an article's stored link, keeping the old one as its
+// original link if it had none.
+func (s *service) SetLink(id int, link string) error {
+	query := `
+		update articles
+		set link = ?, original_link = case when original_link = '' then link else original_link end
+		where id = ?;
+	`
+	_, err := s.db.Exec(query, link, id)
+	if isUniqueViolation(err) {
+		return ErrArticleExists
+	}
+	if err != nil {
+		return fmt.Errorf("error updating link: %v", err)
+	}
+	return nil
+}
+
+func (s *service) SetEmbedding(id int, embedding []byte) error {
+	query := `update articles set embedding = ? where id = ?;`
+	_, err := s.db.Exec(query, embedding, id)
+	if err != nil {
+		return fmt.Errorf("error updating embedding: %v", err)
+	}
+	return nil
+}
+
+func (s *service) SetLinkStatus(id int, status string, checkedAt string) error {
+	query := `update articles set link_status = ?, last_checked = ? where id = ?;`
+	_, err := s.db.Exec(query, status, checkedAt, id)
+	if err != nil {
+		return fmt.Errorf("error updating link status: %v", err)
+	}
+	return nil
+}
+
+// UpsertArticle inserts article, or refreshes the extracted metadata of the
+// row that already has its link. The existing date_read and the user-set
+// fields are preserved: status, pin, progress, rating and notes, which a
+// re-extraction has no values for. article.ID is set to the affected row.
+func (s *service) UpsertArticle(article *types.Article) error {
 	query := `
 		insert into articles (
 			title,
@@ -72,7 +531,24 @@ func (s *service) InsertArticle(article *types.Article) error {
 			date_read,
 			date_published,
 			link,
-			type
+			original_link,
+			type,
+			status,
+			created_at,
+			completed_at,
+			paper_id,
+			extraction_status,
+			extractor,
+			type_uncertain,
+			author_guessed,
+			title_guessed,
+			site_name,
+			suggested_tags,
+			content,
+			img_path,
+			rating,
+			notes,
+			extracted
 		) values(
 			:title,
 			:author,
@@ -80,12 +556,445 @@ func (s *service) InsertArticle(article *types.Article) error {
 			:date_read,
 			:date_published,
 			:link,
-			:type
-		);
+			:original_link,
+			:type,
+			:status,
+			:created_at,
+			:completed_at,
+			:paper_id,
+			:extraction_status,
+			:extractor,
+			:type_uncertain,
+			:author_guessed,
+			:title_guessed,
+			:site_name,
+			:suggested_tags,
+			:content,
+			:img_path,
+			:rating,
+			:notes,
+			:extracted
+		)
+		on conflict(link) do update set
+			original_link = excluded.original_link,
+			title = excluded.title,
+			author = excluded.author,
+			summary = excluded.summary,
+			date_published = excluded.date_published,
+			type = excluded.type,
+			paper_id = excluded.paper_id,
+			extraction_status = excluded.extraction_status,
+			extracted = max(extracted, excluded.extracted),
+			extractor = excluded.extractor,
+			type_uncertain = excluded.type_uncertain,
+			author_guessed = excluded.author_guessed,
+			title_guessed = excluded.title_guessed,
+			site_name = excluded.site_name,
+			suggested_tags = excluded.suggested_tags,
+			content = excluded.content,
+			img_path = case when img_path = '' then excluded.img_path else img_path end
+		returning id;
 	`
-	_, err := s.db.NamedExec(query, &article)
+	prepareInsert(article)
+	rows, err := s.db.NamedQuery(query, article)
 	if err != nil {
-		return fmt.Errorf("error inserting into db: %v", err)
+		return fmt.Errorf("error upserting into db: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error upserting into db: %v", err)
+		}
+		return fmt.Errorf("error upserting into db: no row returned")
+	}
+	return rows.Scan(&article.ID)
+}
+
+// InsertArticlesTx inserts articles in one transaction, along with tags[i]
+// for articles[i] when tags is not nil. Nothing is stored if any insert
+// fails; a duplicate link fails the batch with ErrArticleExists.
+func (s *service) InsertArticlesTx(ctx context.Context, articles []*types.Article, tags [][]string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareNamedContext(ctx, insertArticleQuery)
+	if err != nil {
+		return fmt.Errorf("error preparing insert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, article := range articles {
+		prepareInsert(article)
+		res, err := stmt.ExecContext(ctx, article)
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: %s", ErrArticleExists, article.Link)
+		}
+		if err != nil {
+			return fmt.Errorf("error inserting %s into db: %v", article.Link, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error reading inserted id: %v", err)
+		}
+		article.ID = int(id)
+	}
+
+	for i := range tags {
+		if err := addTags(tx, articles[i].ID, tags[i]); err != nil {
+			return fmt.Errorf("error tagging %s: %v", articles[i].Link, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %v", err)
+	}
+	return nil
+}
+
+// SetProgress records how far through an article the reader is. Starting
+// an unread article moves it to reading.
+func (s *service) SetProgress(id int, progress int) error {
+	query := `
+		update articles
+		set progress = ?1,
+			status = case when ?1 > 0 and status = 'unread' then 'reading' else status end
+		where id = ?2;
+	`
+	res, err := s.db.Exec(query, progress, id)
+	if err != nil {
+		return fmt.Errorf("error updating progress: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+func (s *service) SetImagePath(id int, imagePath string) error {
+	query := `update articles set img_path = ? where id = ?;`
+	res, err := s.db.Exec(query, imagePath, id)
+	if err != nil {
+		return fmt.Errorf("error updating image: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+// DeleteArticle removes an article along with its tags, alternate links and
+// edit history.
+func (s *service) DeleteArticle(id int) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`delete from articles where id = ?;`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting article: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+
+	query := `
+		delete from article_tags where article_id = ?;
+		delete from article_links where article_id = ?;
+		delete from article_history where change_id in (select id from article_changes where article_id = ?);
+		delete from article_changes where article_id = ?;
+	`
+	if _, err := tx.Exec(query, id, id, id, id); err != nil {
+		return fmt.Errorf("error deleting article data: %v", err)
+	}
+	return tx.Commit()
+}
+
+// PatchColumns maps the article fields a client may edit directly, by their
+// JSON names, to their columns.
+var PatchColumns = map[string]string{
+	"title":         "title",
+	"author":        "author",
+	"summary":       "summary",
+	"datePublished": "date_published",
+	"type":          "type",
+	"siteName":      "site_name",
+	"rating":        "rating",
+	"notes":         "notes",
+}
+
+// PatchArticle sets the given fields, keyed by their JSON names. A title,
+// author or type set this way is the user's, so it is no longer flagged as
+// guessed or uncertain.
+func (s *service) PatchArticle(id int, fields map[string]any) error {
+	if len(fields) == 0 {
+		_, err := s.GetArticleByID(id)
+		return err
+	}
+	sets := make([]string, 0, len(fields)+2)
+	args := make([]any, 0, len(fields)+1)
+	for field, value := range fields {
+		column, ok := PatchColumns[field]
+		if !ok {
+			return fmt.Errorf("field %s can't be edited", field)
+		}
+		sets = append(sets, column+" = ?")
+		args = append(args, value)
+		switch field {
+		case "title":
+			sets = append(sets, "title_guessed = 0")
+		case "author":
+			sets = append(sets, "author_guessed = 0")
+		case "type":
+			sets = append(sets, "type_uncertain = 0")
+		}
+	}
+	args = append(args, id)
+
+	query := fmt.Sprintf(`update articles set %s where id = ?;`, strings.Join(sets, ", "))
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("error editing article: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
 	}
 	return nil
 }
+
+const setStatusQuery = `
+	update articles
+	set status = ?1,
+		completed_at = case
+			when ?1 != 'read' then ''
+			when completed_at != '' then completed_at
+			else ?2
+		end,
+		date_read = case
+			when ?1 = 'read' and date_read = '' then ?4
+			else date_read
+		end
+	where id = ?3;
+`
+
+// SetStatus changes an article's reading status. Moving to read stamps
+// completed_at (keeping an earlier stamp), and date_read with today when it
+// has none, both in UTC so they agree on the day; any other status clears
+// completed_at.
+func (s *service) SetStatus(id int, status string) error {
+	now := time.Now().UTC()
+	res, err := s.db.Exec(setStatusQuery, status, now.Format(time.RFC3339), id, now.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("error updating status: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+// SetStatuses changes the reading status of many articles in one
+// transaction, the way SetStatus does for one, and returns how many existed.
+func (s *service) SetStatuses(ids []int, status string) (int, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	completedAt, today := now.Format(time.RFC3339), now.Format("2006-01-02")
+	updated := 0
+	for _, id := range ids {
+		res, err := tx.Exec(setStatusQuery, status, completedAt, id, today)
+		if err != nil {
+			return 0, fmt.Errorf("error updating status: %v", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// GetCompletedArticles returns articles finished at or after completedSince,
+// most recently finished first. Backfilled completed_at values are dates
+// without a time, so both sides are compared as datetimes.
+func (s *service) GetCompletedArticles(completedSince string) (*[]types.Article, error) {
+	articles := make([]types.Article, 0)
+	query := fmt.Sprintf(`
+		select %s from articles
+		where completed_at != '' and datetime(completed_at) >= datetime(?) and %s
+		order by datetime(completed_at) desc, id desc;
+	`, selectList(""), listedCondition(""))
+	err := s.db.Select(&articles, query, completedSince)
+	if err != nil {
+		log.Println("error querying completed articles", err)
+		return nil, err
+	}
+	return &articles, nil
+}
+
+// GetStaleArticles returns unread articles added before the given RFC 3339
+// timestamp, oldest first. Rows backfilled from date_read have a date
+// without a time, so both sides are compared as datetimes.
+func (s *service) GetStaleArticles(addedBefore string) (*[]types.Article, error) {
+	articles := make([]types.Article, 0)
+	query := fmt.Sprintf(`
+		select %s from articles
+		where status = 'unread' and datetime(created_at) < datetime(?) and %s
+		order by datetime(created_at) asc, id asc;
+	`, selectList(""), listedCondition(""))
+	err := s.db.Select(&articles, query, addedBefore)
+	if err != nil {
+		log.Println("error querying stale articles", err)
+		return nil, err
+	}
+	return &articles, nil
+}
+
+// velocityFormats maps a granularity to the strftime format naming its period.
+var velocityFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%Y-W%W",
+	"month": "%Y-%m",
+}
+
+// GetVelocity counts articles added and completed per period, oldest period
+// first. granularity is one of day, week or month.
+func (s *service) GetVelocity(granularity string) ([]types.VelocityPoint, error) {
+	format, ok := velocityFormats[granularity]
+	if !ok {
+		return nil, fmt.Errorf("unknown granularity: %s", granularity)
+	}
+
+	points := make([]types.VelocityPoint, 0)
+	query := fmt.Sprintf(`
+		select period, sum(added) as added, sum(completed) as completed
+		from (
+			select strftime(?1, created_at) as period, 1 as added, 0 as completed
+			from articles where created_at != '' and %[1]s
+			union all
+			select strftime(?1, completed_at), 0, 1
+			from articles where completed_at != '' and %[1]s
+		)
+		where period is not null
+		group by period
+		order by period;
+	`, listedCondition(""))
+	err := s.db.Select(&points, query, format)
+	if err != nil {
+		log.Println("error querying velocity", err)
+		return nil, err
+	}
+	return points, nil
+}
+
+// GetTypeCounts returns the number of articles of each type that has any.
+func (s *service) GetTypeCounts() ([]types.TypeCount, error) {
+	counts := make([]types.TypeCount, 0)
+	query := fmt.Sprintf(`
+		select type, count(*) as count from articles
+		where %s
+		group by type order by type;
+	`, listedCondition(""))
+	err := s.db.Select(&counts, query)
+	if err != nil {
+		log.Println("error counting article types", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+// GetArticleStats totals the complete articles, by type and read this
+// month, in one pass. Backfilled completed_at values are dates without a
+// time, so the month is compared as datetimes.
+func (s *service) GetArticleStats() (*types.ArticleStats, error) {
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	query := fmt.Sprintf(`
+		select
+			count(*),
+			coalesce(sum(type = 0), 0),
+			coalesce(sum(type = 1), 0),
+			coalesce(sum(type = 2), 0),
+			coalesce(sum(status = 'read' and datetime(completed_at) >= datetime(?)), 0),
+			coalesce(min(nullif(date_read, '')), ''),
+			coalesce(max(nullif(date_read, '')), '')
+		from articles
+		where %s;
+	`, listedCondition(""))
+	stats := &types.ArticleStats{}
+	var byType [3]int
+	err := s.db.QueryRow(query, monthStart).Scan(
+		&stats.Total, &byType[types.TypeArticle], &byType[types.TypePaper], &byType[types.TypeBook],
+		&stats.ReadThisMonth, &stats.EarliestDateRead, &stats.LatestDateRead,
+	)
+	if err != nil {
+		log.Println("error computing article stats", err)
+		return nil, err
+	}
+	for _, t := range types.KnownTypes {
+		stats.ByType = append(stats.ByType, types.TypeCount{Type: t, Label: types.TypeLabels[t], Count: byType[t]})
+	}
+	return stats, nil
+}
+
+// GetSiteCounts returns the number of articles from each site, most saved
+// first.
+func (s *service) GetSiteCounts() ([]types.SiteCount, error) {
+	counts := make([]types.SiteCount, 0)
+	query := fmt.Sprintf(`
+		select site_name, count(*) as count from articles
+		where %s and site_name != ''
+		group by site_name collate nocase order by count desc, site_name;
+	`, listedCondition(""))
+	err := s.db.Select(&counts, query)
+	if err != nil {
+		log.Println("error counting article sites", err)
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (s *service) GetArticleByPaperID(paperID string) (*types.Article, error) {
+	article := types.Article{}
+	query := `select * from articles where paper_id = ?;`
+	err := s.db.Get(&article, query, paperID)
+	if err == sql.ErrNoRows {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
@@ -0,0 +1,356 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reading-list-api/internal/types"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGetArticlePageVisitsEachArticleOnce(t *testing.T) {
+	s := newTestService(t)
+	const total = 23
+	for i := 0; i < total; i++ {
+		// every article shares its date and title, so only the id tie-breaker
+		// keeps the pages apart
+		insertTestArticle(t, s, fmt.Sprintf("a%02d", i), func(a *types.Article) {
+			a.Title = "same title"
+			a.CreatedAt = "2024-05-01T00:00:00Z"
+		})
+	}
+
+	orders := []ArticleOrder{
+		{},
+		{Ascending: true},
+		{Sort: "title"},
+		{Sort: "createdAt", Ascending: true},
+		{Sort: "datePublished"},
+	}
+	for _, order := range orders {
+		t.Run(fmt.Sprintf("%+v", order), func(t *testing.T) {
+			filter := ArticleFilter{Order: order}
+			count, err := s.GetArticleCount(filter)
+			if err != nil {
+				t.Fatalf("GetArticleCount: %v", err)
+			}
+			if count != total {
+				t.Fatalf("GetArticleCount = %d, want %d", count, total)
+			}
+
+			seen := make(map[int]bool)
+			const pageSize = 5
+			for offset := 0; offset < total+pageSize; offset += pageSize {
+				page, err := s.GetArticlePage(filter, offset, pageSize)
+				if err != nil {
+					t.Fatalf("GetArticlePage(%d): %v", offset, err)
+				}
+				for _, article := range *page {
+					if seen[article.ID] {
+						t.Errorf("article %d listed twice", article.ID)
+					}
+					seen[article.ID] = true
+				}
+			}
+			if len(seen) != total {
+				t.Errorf("listed %d articles, want %d", len(seen), total)
+			}
+		})
+	}
+}
+
+func TestEachArticleReadsInBatches(t *testing.T) {
+	s := newTestService(t)
+	const total = 23
+	for i := 0; i < total; i++ {
+		insertTestArticle(t, s, fmt.Sprintf("a%02d", i), func(a *types.Article) {
+			a.Title = "same title"
+		})
+	}
+	batch := eachArticleBatch
+	eachArticleBatch = 5
+	t.Cleanup(func() { eachArticleBatch = batch })
+
+	order := ArticleOrder{Sort: "title"}
+	want, err := s.GetArticlePage(ArticleFilter{Order: order}, 0, total)
+	if err != nil {
+		t.Fatalf("GetArticlePage: %v", err)
+	}
+	var got []int
+	err = s.EachArticle(context.Background(), order, func(article *types.Article) error {
+		got = append(got, article.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachArticle: %v", err)
+	}
+	if len(got) != len(*want) {
+		t.Fatalf("EachArticle visited %d articles, want %d", len(got), len(*want))
+	}
+	for i, article := range *want {
+		if got[i] != article.ID {
+			t.Fatalf("article %d is %d, want %d", i, got[i], article.ID)
+		}
+	}
+}
+
+func TestDeleteArticle(t *testing.T) {
+	s := newTestService(t)
+	article := insertTestArticle(t, s, "doomed")
+	kept := insertTestArticle(t, s, "kept")
+	for _, a := range []*types.Article{article, kept} {
+		if err := s.AddTags(a.ID, []string{"go"}); err != nil {
+			t.Fatalf("AddTags: %v", err)
+		}
+		if err := s.AddArticleLink(&types.ArticleLink{ArticleID: a.ID, Link: a.Link + "/mirror"}); err != nil {
+			t.Fatalf("AddArticleLink: %v", err)
+		}
+		change := &types.ArticleChange{
+			ArticleID: a.ID,
+			Source:    "test",
+			Fields:    []types.FieldChange{{Field: "title", OldValue: "", NewValue: a.Title}},
+		}
+		if err := s.RecordChange(change); err != nil {
+			t.Fatalf("RecordChange: %v", err)
+		}
+	}
+
+	if err := s.DeleteArticle(article.ID); err != nil {
+		t.Fatalf("DeleteArticle: %v", err)
+	}
+	if _, err := s.GetArticleByID(article.ID); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("GetArticleByID after delete = %v, want ErrArticleNotFound", err)
+	}
+	if err := s.DeleteArticle(article.ID); !errors.Is(err, ErrArticleNotFound) {
+		t.Errorf("deleting again = %v, want ErrArticleNotFound", err)
+	}
+
+	counts := map[string]string{
+		"article_tags":    `select count(*) from article_tags where article_id = ?`,
+		"article_links":   `select count(*) from article_links where article_id = ?`,
+		"article_changes": `select count(*) from article_changes where article_id = ?`,
+		"article_history": `select count(*) from article_history where change_id in (select id from article_changes where article_id = ?)`,
+	}
+	for table, query := range counts {
+		var deleted, remaining int
+		if err := s.db.Get(&deleted, query, article.ID); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		if err := s.db.Get(&remaining, query, kept.ID); err != nil {
+			t.Fatalf("counting %s: %v", table, err)
+		}
+		if deleted != 0 {
+			t.Errorf("%s keeps %d rows of the deleted article", table, deleted)
+		}
+		if remaining != 1 {
+			t.Errorf("%s has %d rows of the other article, want 1", table, remaining)
+		}
+	}
+	var orphans int
+	if err := s.db.Get(&orphans, `select count(*) from article_history where change_id not in (select id from article_changes)`); err != nil {
+		t.Fatalf("counting article_history: %v", err)
+	}
+	if orphans != 0 {
+		t.Errorf("article_history keeps %d rows of deleted changes", orphans)
+	}
+}
+
+func TestAuthorFilter(t *testing.T) {
+	s := newTestService(t)
+	authors := []string{"Ada Lovelace", "ADA BYRON", "100% Human", "1000 Humans", "snake_case", "snakeXcase", `back\slash`}
+	for i, author := range authors {
+		insertTestArticle(t, s, fmt.Sprintf("a%d", i), func(a *types.Article) {
+			a.Author = author
+		})
+	}
+
+	tests := []struct {
+		author string
+		want   []string
+	}{
+		{"ada", []string{"Ada Lovelace", "ADA BYRON"}},
+		{"LOVELACE", []string{"Ada Lovelace"}},
+		{"aDa bY", []string{"ADA BYRON"}},
+		{"100%", []string{"100% Human"}},
+		{"%", []string{"100% Human"}},
+		{"e_c", []string{"snake_case"}},
+		{"_", []string{"snake_case"}},
+		{`k\s`, []string{`back\slash`}},
+		{"nobody", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.author, func(t *testing.T) {
+			page, err := s.GetArticlePage(ArticleFilter{Author: tt.author, Order: ArticleOrder{Sort: "id", Ascending: true}}, 0, -1)
+			if err != nil {
+				t.Fatalf("GetArticlePage: %v", err)
+			}
+			var got []string
+			for _, article := range *page {
+				got = append(got, article.Author)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("author %q lists %q, want %q", tt.author, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetArticleStats(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now().UTC()
+	thisMonth := now.Format(time.RFC3339)
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(time.RFC3339)
+
+	seed := []func(*types.Article){
+		func(a *types.Article) { a.DateRead = "2024-03-15" },
+		func(a *types.Article) { a.DateRead = "" },
+		func(a *types.Article) { a.Type = types.TypePaper; a.DateRead = "2024-01-02" },
+		func(a *types.Article) {
+			a.Type = types.TypeBook
+			a.Status = types.StatusRead
+			a.CompletedAt = thisMonth
+		},
+		func(a *types.Article) {
+			a.Status = types.StatusRead
+			a.CompletedAt = lastMonth
+			a.DateRead = "2024-07-30"
+		},
+		func(a *types.Article) {
+			a.Type = types.TypeBook
+			a.DateRead = "2023-01-01"
+			a.ExtractionStatus = types.ExtractionPending
+		},
+	}
+	for i, change := range seed {
+		insertTestArticle(t, s, fmt.Sprintf("a%d", i), change)
+	}
+
+	stats, err := s.GetArticleStats()
+	if err != nil {
+		t.Fatalf("GetArticleStats: %v", err)
+	}
+	if stats.Total != 5 {
+		t.Errorf("Total = %d, want 5", stats.Total)
+	}
+	byType := map[int]int{}
+	for _, count := range stats.ByType {
+		byType[count.Type] = count.Count
+	}
+	want := map[int]int{types.TypeArticle: 3, types.TypePaper: 1, types.TypeBook: 1}
+	if !maps.Equal(byType, want) {
+		t.Errorf("ByType = %v, want %v", byType, want)
+	}
+	if stats.ReadThisMonth != 1 {
+		t.Errorf("ReadThisMonth = %d, want 1", stats.ReadThisMonth)
+	}
+	// the undated article and the pending one don't count
+	if stats.EarliestDateRead != "2024-01-02" {
+		t.Errorf("EarliestDateRead = %q, want 2024-01-02", stats.EarliestDateRead)
+	}
+	if stats.LatestDateRead != "2024-07-30" {
+		t.Errorf("LatestDateRead = %q, want 2024-07-30", stats.LatestDateRead)
+	}
+}
+
+func TestGetArticleStatsDateOnlyCompletion(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now().UTC()
+	// a completion backfilled from date_read on the first of the month
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	insertTestArticle(t, s, "backfilled", func(a *types.Article) {
+		a.Status = types.StatusRead
+		a.CompletedAt = firstOfMonth
+	})
+
+	stats, err := s.GetArticleStats()
+	if err != nil {
+		t.Fatalf("GetArticleStats: %v", err)
+	}
+	if stats.ReadThisMonth != 1 {
+		t.Errorf("ReadThisMonth = %d with an article completed on %s, want 1", stats.ReadThisMonth, firstOfMonth)
+	}
+}
+
+func TestGetCompletedArticlesDateOnlyCompletion(t *testing.T) {
+	s := newTestService(t)
+	completions := map[string]string{
+		"before":     "2024-04-30",
+		"backfilled": "2024-05-01",
+		"stamped":    "2024-05-01T09:30:00Z",
+		"later":      "2024-05-02",
+	}
+	for title, completedAt := range completions {
+		insertTestArticle(t, s, title, func(a *types.Article) {
+			a.Status = types.StatusRead
+			a.CompletedAt = completedAt
+		})
+	}
+
+	articles, err := s.GetCompletedArticles("2024-05-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("GetCompletedArticles: %v", err)
+	}
+	var got []string
+	for _, article := range *articles {
+		got = append(got, article.Title)
+	}
+	if want := []string{"later", "stamped", "backfilled"}; !slices.Equal(got, want) {
+		t.Errorf("completed since May 1 lists %q, want %q", got, want)
+	}
+}
+
+func TestPatchArticleRating(t *testing.T) {
+	s := newTestService(t)
+	article := insertTestArticle(t, s, "rated")
+
+	if err := s.PatchArticle(article.ID, map[string]any{"rating": 4, "notes": "worth a reread"}); err != nil {
+		t.Fatalf("PatchArticle: %v", err)
+	}
+	stored, err := s.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.Rating == nil || *stored.Rating != 4 || stored.Notes != "worth a reread" {
+		t.Fatalf("stored rating %v and notes %q, want 4 and the notes", stored.Rating, stored.Notes)
+	}
+
+	if err := s.PatchArticle(article.ID, map[string]any{"rating": nil}); err != nil {
+		t.Fatalf("PatchArticle: %v", err)
+	}
+	stored, err = s.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.Rating != nil {
+		t.Errorf("rating %d after clearing it, want unrated", *stored.Rating)
+	}
+	if stored.Notes != "worth a reread" {
+		t.Errorf("notes %q after clearing the rating, want them kept", stored.Notes)
+	}
+}
+
+func TestUpsertArticleKeepsRatingAndNotes(t *testing.T) {
+	s := newTestService(t)
+	article := insertTestArticle(t, s, "rated")
+	if err := s.PatchArticle(article.ID, map[string]any{"rating": 5, "notes": "mine"}); err != nil {
+		t.Fatalf("PatchArticle: %v", err)
+	}
+
+	refreshed := &types.Article{Title: "re-extracted", Link: article.Link, DateRead: "2024-06-01"}
+	if err := s.UpsertArticle(refreshed); err != nil {
+		t.Fatalf("UpsertArticle: %v", err)
+	}
+	stored, err := s.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.Title != "re-extracted" {
+		t.Errorf("title %q, want the refreshed one", stored.Title)
+	}
+	if stored.Rating == nil || *stored.Rating != 5 || stored.Notes != "mine" {
+		t.Errorf("rating %v and notes %q after the upsert, want 5 and mine", stored.Rating, stored.Notes)
+	}
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reading-list-api/internal/types"
+)
+
+// ArticleCursor marks the last article of a page in keyset pagination: its
+// place in the listing order rather than an offset, so articles saved or
+// removed while a client scrolls don't shift the pages after it.
+type ArticleCursor struct {
+	// Order is the listing order the cursor was made in.
+	Order     ArticleOrder `json:"order"`
+	Pinned    bool         `json:"pinned,omitempty"`
+	SortOrder int          `json:"sortOrder,omitempty"`
+	// Value is the article's value of the sort column, unused when
+	// sorting by id.
+	Value string `json:"value,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// CursorAfter returns the cursor that continues a listing after article.
+func CursorAfter(article *types.Article, order ArticleOrder) ArticleCursor {
+	cursor := ArticleCursor{
+		Order:     order,
+		Pinned:    article.Pinned,
+		SortOrder: article.SortOrder,
+		ID:        article.ID,
+	}
+	switch order.Sort {
+	case "createdAt":
+		cursor.Value = article.CreatedAt
+	case "datePublished":
+		cursor.Value = article.DatePublished
+	case "title":
+		cursor.Value = article.Title
+	case "id":
+	default:
+		cursor.Value = article.DateRead
+	}
+	return cursor
+}
+
+// Encode returns the cursor as an opaque token for a client to pass back.
+func (c ArticleCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// ParseArticleCursor reads a token made by Encode.
+func ParseArticleCursor(token string) (ArticleCursor, error) {
+	var cursor ArticleCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID < 1 {
+		return cursor, errInvalidCursor
+	}
+	if _, ok := SortColumns[cursor.Order.Sort]; !ok && cursor.Order.Sort != "" {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// where builds the keyset condition for the rows after the cursor, following
+// orderBy key by key: pinned first, then the manual sort order, the sort
+// column and finally id.
+func (c ArticleCursor) where() (string, []any) {
+	column, ok := SortColumns[c.Order.Sort]
+	if !ok {
+		column = SortColumns["dateRead"]
+	}
+	op := "<"
+	if c.Order.Ascending {
+		op = ">"
+	}
+	pinned := 0
+	if c.Pinned {
+		pinned = 1
+	}
+
+	if column == "id" {
+		return fmt.Sprintf(`(pinned < ?
+			or (pinned = ? and sort_order > ?)
+			or (pinned = ? and sort_order = ? and id %s ?))`, op),
+			[]any{pinned, pinned, c.SortOrder, pinned, c.SortOrder, c.ID}
+	}
+	return fmt.Sprintf(`(pinned < ?
+		or (pinned = ? and sort_order > ?)
+		or (pinned = ? and sort_order = ? and %[1]s %[2]s ?)
+		or (pinned = ? and sort_order = ? and %[1]s = ? and id %[2]s ?))`, column, op),
+		[]any{pinned, pinned, c.SortOrder, pinned, c.SortOrder, c.Value, pinned, c.SortOrder, c.Value, c.ID}
+}
+
+// GetArticlePageAfterCursor returns up to limit articles of the listing that
+// follow the cursor, or the first ones when it is nil. It pages in
+// filter.Order, which a cursor must have been made in.
+func (s *service) GetArticlePageAfterCursor(filter ArticleFilter, after *ArticleCursor, limit int) (*[]types.Article, error) {
+	articles := make([]types.Article, 0)
+	where, args := filter.where()
+	if after != nil {
+		keyset, keysetArgs := after.where()
+		where += " and " + keyset
+		args = append(args, keysetArgs...)
+	}
+	query := fmt.Sprintf(`
+		select %s from articles
+		%s
+		%s
+		limit ?;
+	`, selectList(""), where, filter.Order.orderBy())
+
+	err := s.db.Select(&articles, query, append(args, limit)...)
+	if err != nil {
+		log.Println("error querying articles after cursor", err)
+		return nil, err
+	}
+	return &articles, nil
+}
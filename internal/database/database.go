@@ -2,30 +2,96 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"reading-list-api/internal/types"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/joho/godotenv/autoload"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// Store is the core of article storage: saving, reading and listing
+// articles, their extraction, tags and history, and editing their reading
+// state. Service is a Store backed by SQLite; Memory is one kept in a map,
+// for code that only needs these.
+type Store interface {
+	GetAllArticles(ArticleOrder) (*[]types.Article, error)
+	EachArticle(context.Context, ArticleOrder, func(*types.Article) error) error
+	GetArticlePage(ArticleFilter, int, int) (*[]types.Article, error)
+	GetArticlePageAfterCursor(ArticleFilter, *ArticleCursor, int) (*[]types.Article, error)
+	GetArticleCount(ArticleFilter) (int, error)
+	GetMaxArticleID() (int, error)
+	ArticleExists(string) (bool, error)
+	InsertArticle(*types.Article) error
+	UpsertArticle(*types.Article) error
+	GetArticleByID(int) (*types.Article, error)
+	GetArticlesByIDs([]int) (*[]types.Article, error)
+	GetArticleByLink(string) (*types.Article, error)
+	GetArticleByPaperID(string) (*types.Article, error)
+	TogglePinned(int, *int) error
+	SetStatus(int, string) error
+	SetProgress(int, int) error
+	SetImagePath(int, string) error
+	SetArchiveURL(int, string) error
+	SetStatuses([]int, string) (int, error)
+	PatchArticle(int, map[string]any) error
+	InsertArticlesTx(context.Context, []*types.Article, [][]string) error
+	DeleteArticle(int) error
+	QueueExtraction(int, int) error
+	ClaimExtraction(int) (bool, error)
+	CompleteExtraction(*types.Article) error
+	FailExtraction(int, string, bool) error
+	RetryExtraction(int) error
+	GetPendingExtractionIDs() ([]int, error)
+	RequeueInterruptedExtractions() error
+	AddTags(int, []string) error
+	RemoveTag(int, string) error
+	GetTagsForArticle(int) ([]string, error)
+	GetTagsByArticle() (map[int][]string, error)
+	GetAllTags() ([]string, error)
+	RecordChange(*types.ArticleChange) error
+	GetArticleHistory(int) ([]types.ArticleChange, error)
+	SetHistoryFields(int, map[string]string) error
+	// DataVersion changes whenever a row is inserted, updated or deleted,
+	// so cached reads can tell they are stale.
+	DataVersion() uint64
+}
+
 // Service represents a service that interacts with a database.
 type Service interface {
+	Store
+
 	// Health returns a map of health status information.
 	// The keys and values in the map are service-specific.
 	Health() map[string]string
 
 	// DB ops
-	GetAllArticles() (*[]types.Article, error)
-	GetArticlePage(int, int) (*[]types.Article, error)
-	ArticleExists(string) (bool, error)
-	GetArticleCount() (int, error)
-	InsertArticle(*types.Article) error
+	SetLinkStatus(int, string, string) error
+	SearchArticles(string, int, int) (*[]types.Article, error)
+	GetSearchCount(string) (int, error)
+	GetStaleArticles(string) (*[]types.Article, error)
+	GetArticleStats() (*types.ArticleStats, error)
+	GetCompletedArticles(string) (*[]types.Article, error)
+	GetVelocity(string) ([]types.VelocityPoint, error)
+	GetTypeCounts() ([]types.TypeCount, error)
+	GetSiteCounts() ([]types.SiteCount, error)
+	SetEmbedding(int, []byte) error
+	GetEmbeddedArticles() (*[]types.Article, error)
+	SetSummary(int, string) error
+	SetLink(int, string) error
+	AddArticleLink(*types.ArticleLink) error
+	GetArticleLinks(int) ([]types.ArticleLink, error)
+	SetGoal(types.Goal) error
+	GetGoals() ([]types.Goal, error)
+	CountCompleted(int, string, string) (int, error)
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
 	Close() error
@@ -33,38 +99,115 @@ type Service interface {
 
 type service struct {
 	db *sqlx.DB
+	// fts is set when the FTS5 search index is in use
+	fts bool
+	// failedMigrations are the statement migrations that couldn't be
+	// applied, reported by Health until they are resolved
+	failedMigrations []string
+}
+
+// versionedDriver is the sqlite3 driver with an update hook that counts row
+// changes on every connection, whichever query made them.
+const versionedDriver = "sqlite3_versioned"
+
+var dataVersion atomic.Uint64
+
+func init() {
+	sql.Register(versionedDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			conn.RegisterUpdateHook(func(op int, db string, table string, rowid int64) {
+				dataVersion.Add(1)
+			})
+			return nil
+		},
+	})
+}
+
+func (s *service) DataVersion() uint64 {
+	return dataVersion.Load()
 }
 
 var (
-	dburl      = os.Getenv("DB_URL")
+	dburl      = dbPath()
 	dbInstance *service
 )
 
+// dbPath reads the SQLite file location from DB_PATH, falling back to the
+// older DB_URL setting.
+func dbPath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return os.Getenv("DB_URL")
+}
+
+// prepareDBPath creates the database file's parent directory if needed and
+// checks the file can be opened for writing, so a bad mount fails at startup
+// with a clear message instead of on the first write.
+func prepareDBPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("DB_PATH is not set")
+	}
+	// in-memory databases and sqlite URIs are left to the driver
+	if path == ":memory:" || strings.HasPrefix(path, "file:") {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating database directory for %s: %v", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("database file %s is not writable: %v", path, err)
+	}
+	return f.Close()
+}
+
 func New() Service {
 	// Reuse Connection
 	if dbInstance != nil {
 		return dbInstance
 	}
 
-	db, err := sqlx.Connect("sqlite3", dburl)
+	s, err := open(dburl)
 	if err != nil {
-		// This will not be a connection error, but a DSN parse error or
-		// another initialization error.
 		log.Fatal(err)
 	}
+	dbInstance = s
+	return dbInstance
+}
 
-	dbInstance = &service{
-		db: db,
+// open connects to the SQLite file at path and brings its schema up to date.
+func open(path string) (*service, error) {
+	if err := prepareDBPath(path); err != nil {
+		return nil, err
 	}
 
-	dbInstance.CreateTables()
+	// this will not be a connection error, but a DSN parse error or
+	// another initialization error
+	db, err := sqlx.Connect(versionedDriver, path)
+	if err != nil {
+		return nil, err
+	}
 
-	return dbInstance
+	s := &service{
+		db: db,
+	}
+
+	// a new file gets the full schema here, an existing one is migrated
+	if err := s.CreateTables(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
 }
 
 func (s *service) CreateTables() error {
 	articlesTable := `
-	create table articles (
+	create table if not exists articles (
 		id integer not null primary key,
 		title text not null default '',
 		author text not null default '',
@@ -77,12 +220,197 @@ func (s *service) CreateTables() error {
 	);
 	`
 	_, err := s.db.Exec(articlesTable)
+	if err != nil {
+		log.Println("Error: ", err)
+		return err
+	}
+
+	tagsTables := `
+	create table if not exists tags (
+		id integer not null primary key,
+		name text not null unique
+	);
+	create table if not exists article_tags (
+		article_id integer not null,
+		tag_id integer not null,
+		primary key (article_id, tag_id)
+	);
+	create index if not exists article_tags_tag_id on article_tags(tag_id);
+	`
+	_, err = s.db.Exec(tagsTables)
+	if err != nil {
+		log.Println("Error: ", err)
+		return err
+	}
+
+	linksTable := `
+	create table if not exists article_links (
+		id integer not null primary key,
+		article_id integer not null,
+		link text not null unique,
+		label text not null default '',
+		created_at text not null default ''
+	);
+	create index if not exists article_links_article_id on article_links(article_id);
+	`
+	_, err = s.db.Exec(linksTable)
+	if err != nil {
+		log.Println("Error: ", err)
+		return err
+	}
+
+	historyTables := `
+	create table if not exists article_changes (
+		id integer not null primary key,
+		article_id integer not null,
+		source text not null,
+		changed_at text not null,
+		revert_of integer not null default 0
+	);
+	create index if not exists article_changes_article_id on article_changes(article_id);
+	create table if not exists article_history (
+		change_id integer not null,
+		field text not null,
+		old_value text not null,
+		new_value text not null,
+		primary key (change_id, field)
+	);
+	`
+	_, err = s.db.Exec(historyTables)
+	if err != nil {
+		log.Println("Error: ", err)
+		return err
+	}
+
+	goalsTable := `
+	create table if not exists goals (
+		type integer not null,
+		period text not null,
+		target integer not null,
+		primary key (type, period)
+	);
+	`
+	_, err = s.db.Exec(goalsTable)
 	if err != nil {
 		log.Println("Error: ", err)
 	}
 	return err
 }
 
+// columnMigrations lists columns added to existing tables after the initial
+// schema. They are applied in order on startup and skipped when present.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"articles", "pinned", "integer not null default 0"},
+	{"articles", "sort_order", "integer not null default 0"},
+	{"articles", "archive_url", "text not null default ''"},
+	{"articles", "link_status", "text not null default ''"},
+	{"articles", "last_checked", "text not null default ''"},
+	{"articles", "original_link", "text not null default ''"},
+	{"articles", "status", "text not null default 'read'"},
+	{"articles", "created_at", "text not null default ''"},
+	{"articles", "completed_at", "text not null default ''"},
+	{"articles", "paper_id", "text not null default ''"},
+	{"articles", "extraction_status", "text not null default 'complete'"},
+	{"articles", "extraction_error", "text not null default ''"},
+	{"articles", "extraction_attempts", "integer not null default 0"},
+	{"articles", "embedding", "blob"},
+	{"articles", "extractor", "text not null default ''"},
+	{"articles", "type_uncertain", "integer not null default 0"},
+	{"articles", "content", "text not null default ''"},
+	{"articles", "author_guessed", "integer not null default 0"},
+	{"articles", "progress", "integer not null default 0"},
+	{"articles", "extraction_max_attempts", "integer not null default 0"},
+	{"articles", "site_name", "text not null default ''"},
+	{"articles", "suggested_tags", "text not null default ''"},
+	{"articles", "title_guessed", "integer not null default 0"},
+	{"articles", "rating", "integer"},
+	{"articles", "notes", "text not null default ''"},
+	{"articles", "extracted", "integer not null default 0"},
+}
+
+// statementMigrations are idempotent statements run after the column
+// migrations, such as indexes and backfills. A failure is logged and
+// reported by Health rather than fatal, since existing data may violate a
+// new constraint.
+var statementMigrations = []string{
+	`create unique index if not exists articles_link_unique on articles(link);`,
+	// rows from before created_at existed were added on the day they were read
+	`update articles set created_at = date_read where created_at = '';`,
+	`update articles set completed_at = date_read where status = 'read' and completed_at = '';`,
+	`create unique index if not exists articles_paper_id_unique on articles(paper_id) where paper_id != '';`,
+	`update articles set extracted = 1 where extraction_status = 'complete' and extracted = 0;`,
+}
+
+// Migrate brings an existing database up to the current schema.
+func (s *service) Migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := s.columnExists(m.table, m.column)
+		if err != nil {
+			return fmt.Errorf("error inspecting %s.%s: %v", m.table, m.column, err)
+		}
+		if exists {
+			continue
+		}
+		stmt := fmt.Sprintf("alter table %s add column %s %s;", m.table, m.column, m.definition)
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("error adding column %s.%s: %v", m.table, m.column, err)
+		}
+	}
+	if err := s.migratePendingLinks(); err != nil {
+		return fmt.Errorf("error migrating pending links: %v", err)
+	}
+	s.failedMigrations = nil
+	for _, stmt := range statementMigrations {
+		if _, err := s.db.Exec(stmt); err != nil {
+			log.Printf("error applying migration, resolve conflicting rows and restart: %v\n%s", err, stmt)
+			s.failedMigrations = append(s.failedMigrations, fmt.Sprintf("%s: %v", strings.TrimSuffix(strings.TrimSpace(stmt), ";"), err))
+		}
+	}
+	s.fts = s.setupSearchIndex()
+	return nil
+}
+
+// migratePendingLinks moves links queued by maintenance mode, which used to
+// live in their own table, into articles as pending extractions.
+func (s *service) migratePendingLinks() error {
+	var count int
+	err := s.db.QueryRow(`select count(*) from sqlite_master where type = 'table' and name = 'pending_links';`).Scan(&count)
+	if err != nil || count == 0 {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`
+		insert or ignore into articles (link, original_link, date_read, created_at, status, extraction_status)
+		select link, link, date(created_at), created_at, 'unread', 'pending' from pending_links order by id;
+	`)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`drop table pending_links;`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *service) columnExists(table string, column string) (bool, error) {
+	var count int
+	query := `select count(*) from pragma_table_info(?) where name = ?;`
+	err := s.db.QueryRow(query, table, column).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // Health checks the health of the database connection by pinging the database.
 // It returns a map with keys indicating various health statistics.
 func (s *service) Health() map[string]string {
@@ -131,6 +459,13 @@ func (s *service) Health() map[string]string {
 		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
 	}
 
+	// a constraint that couldn't be created isn't enforced, which matters
+	// more than the pool statistics above
+	if len(s.failedMigrations) > 0 {
+		stats["failed_migrations"] = strings.Join(s.failedMigrations, "; ")
+		stats["message"] = "Some migrations failed, resolve the conflicting rows and restart."
+	}
+
 	return stats
 }
 
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"path/filepath"
+	"reading-list-api/internal/types"
+	"testing"
+)
+
+// newTestService opens a fresh database in a temporary directory.
+func newTestService(t *testing.T) *service {
+	t.Helper()
+	s, err := open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { s.db.Close() })
+	return s
+}
+
+// insertTestArticle saves a complete article with the given title under a
+// link made from it, applying any changes first.
+func insertTestArticle(t *testing.T, s *service, title string, changes ...func(*types.Article)) *types.Article {
+	t.Helper()
+	article := &types.Article{
+		Title:    title,
+		Summary:  "summary of " + title,
+		DateRead: "2024-05-01",
+		Link:     "https://example.com/" + title,
+	}
+	for _, change := range changes {
+		change(article)
+	}
+	if err := s.InsertArticle(article); err != nil {
+		t.Fatalf("InsertArticle(%q): %v", title, err)
+	}
+	return article
+}
@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"reading-list-api/internal/types"
+)
+
+// GetArticleByLink finds the article saved under link, either as its link
+// or as one of its alternate links.
+func (s *service) GetArticleByLink(link string) (*types.Article, error) {
+	article := types.Article{}
+	query := `
+		select * from articles
+		where link = ?1 or id in (select article_id from article_links where link = ?1)
+		order by link = ?1 desc
+		limit 1;
+	`
+	err := s.db.Get(&article, query, link)
+	if err == sql.ErrNoRows {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+// QueueExtraction marks an existing article for re-extraction. Its current
+// metadata stays in place until the new extraction completes, and an
+// article that was extracted before stays listed meanwhile.
+func (s *service) QueueExtraction(id int, maxAttempts int) error {
+	query := `
+		update articles
+		set extraction_status = 'pending', extraction_error = '', extraction_attempts = 0,
+			extraction_max_attempts = ?
+		where id = ?;
+	`
+	return s.execOne(query, maxAttempts, id)
+}
+
+// ClaimExtraction moves a pending article to processing and counts the
+// attempt. It reports false when the article was not pending, e.g. because
+// another worker took it.
+func (s *service) ClaimExtraction(id int) (bool, error) {
+	query := `
+		update articles
+		set extraction_status = 'processing', extraction_attempts = extraction_attempts + 1
+		where id = ? and extraction_status = 'pending';
+	`
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CompleteExtraction stores the extracted metadata on a processing article
+// and marks it complete.
+func (s *service) CompleteExtraction(article *types.Article) error {
+	query := `
+		update articles set
+			title = :title,
+			author = :author,
+			summary = :summary,
+			date_published = :date_published,
+			link = :link,
+			type = :type,
+			paper_id = :paper_id,
+			extractor = :extractor,
+			type_uncertain = :type_uncertain,
+			author_guessed = :author_guessed,
+			title_guessed = :title_guessed,
+			site_name = :site_name,
+			suggested_tags = :suggested_tags,
+			content = :content,
+			img_path = case when img_path = '' then :img_path else img_path end,
+			extraction_status = 'complete',
+			extraction_error = '',
+			extracted = 1
+		where id = :id;
+	`
+	res, err := s.db.NamedExec(query, article)
+	if isUniqueViolation(err) {
+		return ErrArticleExists
+	}
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+// FailExtraction records why an extraction failed. With retry the article
+// goes back to pending for another automatic attempt, otherwise it stays
+// failed until RetryExtraction. A re-extraction that fails for good leaves
+// an article that already had metadata complete, with the error recorded,
+// so it doesn't drop out of listings.
+func (s *service) FailExtraction(id int, reason string, retry bool) error {
+	status := types.ExtractionFailed
+	if retry {
+		status = types.ExtractionPending
+	}
+	query := `
+		update articles
+		set extraction_status = case when ?1 = 'failed' and title != '' then 'complete' else ?1 end,
+			extraction_error = ?2
+		where id = ?3;
+	`
+	return s.execOne(query, status, reason, id)
+}
+
+// RetryExtraction sends a failed article back to pending with a fresh
+// attempt budget. It returns ErrArticleNotFound unless the article exists
+// and has failed.
+func (s *service) RetryExtraction(id int) error {
+	query := `
+		update articles
+		set extraction_status = 'pending', extraction_attempts = 0
+		where id = ? and extraction_status = 'failed';
+	`
+	return s.execOne(query, id)
+}
+
+// GetPendingExtractionIDs returns the articles waiting for extraction, oldest
+// first.
+func (s *service) GetPendingExtractionIDs() ([]int, error) {
+	ids := make([]int, 0)
+	query := `select id from articles where extraction_status = 'pending' order by id asc;`
+	err := s.db.Select(&ids, query)
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// RequeueInterruptedExtractions resets articles left processing by a
+// previous run to pending, as nothing is working on them anymore.
+func (s *service) RequeueInterruptedExtractions() error {
+	_, err := s.db.Exec(`update articles set extraction_status = 'pending' where extraction_status = 'processing';`)
+	return err
+}
+
+// execOne runs an update on a single article, returning ErrArticleNotFound
+// when no row matched.
+func (s *service) execOne(query string, args ...any) error {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
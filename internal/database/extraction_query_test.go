@@ -0,0 +1,50 @@
+package database
+
+import (
+	"reading-list-api/internal/types"
+	"testing"
+)
+
+func TestQueueExtractionKeepsArticleListed(t *testing.T) {
+	s := newTestService(t)
+	article := insertTestArticle(t, s, "extracted")
+	insertTestArticle(t, s, "queued", func(a *types.Article) {
+		a.ExtractionStatus = types.ExtractionPending
+	})
+
+	if err := s.QueueExtraction(article.ID, 0); err != nil {
+		t.Fatalf("QueueExtraction: %v", err)
+	}
+	counts := map[string]ArticleFilter{
+		"listed":  {},
+		"pending": {ExtractionStatus: types.ExtractionPending},
+	}
+	want := map[string]int{"listed": 1, "pending": 2}
+	for name, filter := range counts {
+		count, err := s.GetArticleCount(filter)
+		if err != nil {
+			t.Fatalf("GetArticleCount: %v", err)
+		}
+		if count != want[name] {
+			t.Errorf("%s count = %d while re-extracting, want %d", name, count, want[name])
+		}
+	}
+	stats, err := s.GetArticleStats()
+	if err != nil {
+		t.Fatalf("GetArticleStats: %v", err)
+	}
+	if stats.Total != 1 {
+		t.Errorf("stats total = %d while re-extracting, want 1", stats.Total)
+	}
+
+	if ok, err := s.ClaimExtraction(article.ID); !ok || err != nil {
+		t.Fatalf("ClaimExtraction = %t, %v", ok, err)
+	}
+	page, err := s.GetArticlePage(ArticleFilter{}, 0, -1)
+	if err != nil {
+		t.Fatalf("GetArticlePage: %v", err)
+	}
+	if len(*page) != 1 || (*page)[0].Title != "extracted" {
+		t.Errorf("listing %v while processing, want the article with its metadata", articleIDs(*page))
+	}
+}
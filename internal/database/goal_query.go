@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"reading-list-api/internal/types"
+)
+
+// SetGoal creates or replaces the goal for a type and period. A target of 0
+// removes it.
+func (s *service) SetGoal(goal types.Goal) error {
+	if goal.Target == 0 {
+		_, err := s.db.Exec(`delete from goals where type = ? and period = ?;`, goal.Type, goal.Period)
+		if err != nil {
+			return fmt.Errorf("error deleting goal: %v", err)
+		}
+		return nil
+	}
+
+	query := `
+		insert into goals (type, period, target) values (:type, :period, :target)
+		on conflict (type, period) do update set target = excluded.target;
+	`
+	_, err := s.db.NamedExec(query, goal)
+	if err != nil {
+		return fmt.Errorf("error saving goal: %v", err)
+	}
+	return nil
+}
+
+func (s *service) GetGoals() ([]types.Goal, error) {
+	goals := make([]types.Goal, 0)
+	err := s.db.Select(&goals, `select * from goals order by type, period;`)
+	if err != nil {
+		log.Println("error querying goals", err)
+		return nil, err
+	}
+	return goals, nil
+}
+
+// CountCompleted counts the articles of a type finished in [from, until),
+// both RFC 3339 timestamps in UTC like completed_at. Backfilled completed_at
+// values are dates without a time, so both sides are compared as datetimes.
+func (s *service) CountCompleted(articleType int, from string, until string) (int, error) {
+	var count int
+	query := fmt.Sprintf(`
+		select count(*) from articles
+		where type = ? and datetime(completed_at) >= datetime(?) and datetime(completed_at) < datetime(?) and %s;
+	`, listedCondition(""))
+	err := s.db.QueryRow(query, articleType, from, until).Scan(&count)
+	if err != nil {
+		log.Println("error counting completed articles", err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"reading-list-api/internal/types"
+	"testing"
+)
+
+func TestCountCompletedDateOnlyBoundaries(t *testing.T) {
+	s := newTestService(t)
+	completions := map[string]string{
+		"before":        "2026-09-30",
+		"first day":     "2026-10-01",
+		"stamped":       "2026-10-15T08:00:00Z",
+		"last day":      "2026-10-31",
+		"next period":   "2026-11-01",
+		"next, stamped": "2026-11-01T00:00:00Z",
+	}
+	for title, completedAt := range completions {
+		insertTestArticle(t, s, title, func(a *types.Article) {
+			a.Status = types.StatusRead
+			a.CompletedAt = completedAt
+		})
+	}
+
+	count, err := s.CountCompleted(types.TypeArticle, "2026-10-01T00:00:00Z", "2026-11-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("CountCompleted: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("CountCompleted for October = %d, want 3", count)
+	}
+}
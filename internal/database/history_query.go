@@ -0,0 +1,169 @@
+package database
+
+import (
+	"fmt"
+	"reading-list-api/internal/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyColumns maps the article fields kept in the history, by their JSON
+// names, to their columns. Only these can be restored by a revert.
+var historyColumns = map[string]string{
+	"title":         "title",
+	"author":        "author",
+	"summary":       "summary",
+	"datePublished": "date_published",
+	"link":          "link",
+	"img_path":      "img_path",
+	"type":          "type",
+	"status":        "status",
+	"completedAt":   "completed_at",
+	"progress":      "progress",
+	"pinned":        "pinned",
+	"sortOrder":     "sort_order",
+	"siteName":      "site_name",
+	"rating":        "rating",
+	"notes":         "notes",
+}
+
+// HistoryValues returns the history fields of an article as the strings
+// they are recorded as.
+func HistoryValues(article *types.Article) map[string]string {
+	pinned := "0"
+	if article.Pinned {
+		pinned = "1"
+	}
+	rating := ""
+	if article.Rating != nil {
+		rating = strconv.Itoa(*article.Rating)
+	}
+	return map[string]string{
+		"title":         article.Title,
+		"author":        article.Author,
+		"summary":       article.Summary,
+		"datePublished": article.DatePublished,
+		"link":          article.Link,
+		"img_path":      article.ImagePath,
+		"type":          strconv.Itoa(article.Type),
+		"status":        article.Status,
+		"completedAt":   article.CompletedAt,
+		"progress":      strconv.Itoa(article.Progress),
+		"pinned":        pinned,
+		"sortOrder":     strconv.Itoa(article.SortOrder),
+		"siteName":      article.SiteName,
+		"rating":        rating,
+		"notes":         article.Notes,
+	}
+}
+
+// RecordChange stores an edit and the fields it changed. An edit that
+// changed nothing is not stored.
+func (s *service) RecordChange(change *types.ArticleChange) error {
+	if len(change.Fields) == 0 {
+		return nil
+	}
+	if change.ChangedAt == "" {
+		change.ChangedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.NamedExec(`
+		insert into article_changes (article_id, source, changed_at, revert_of)
+		values (:article_id, :source, :changed_at, :revert_of);
+	`, change)
+	if err != nil {
+		return fmt.Errorf("error inserting article change: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	change.ID = int(id)
+	for i := range change.Fields {
+		change.Fields[i].ChangeID = change.ID
+	}
+	_, err = tx.NamedExec(`
+		insert into article_history (change_id, field, old_value, new_value)
+		values (:change_id, :field, :old_value, :new_value);
+	`, change.Fields)
+	if err != nil {
+		return fmt.Errorf("error inserting article history: %v", err)
+	}
+	return tx.Commit()
+}
+
+// GetArticleHistory lists the recorded edits of an article, newest first.
+func (s *service) GetArticleHistory(articleID int) ([]types.ArticleChange, error) {
+	changes := make([]types.ArticleChange, 0)
+	err := s.db.Select(&changes, `select * from article_changes where article_id = ? order by id desc;`, articleID)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []types.FieldChange
+	query := `
+		select h.* from article_history h
+		join article_changes c on c.id = h.change_id
+		where c.article_id = ?
+		order by h.field;
+	`
+	if err := s.db.Select(&fields, query, articleID); err != nil {
+		return nil, err
+	}
+	byChange := make(map[int][]types.FieldChange, len(changes))
+	for _, field := range fields {
+		byChange[field.ChangeID] = append(byChange[field.ChangeID], field)
+	}
+	for i := range changes {
+		changes[i].Fields = byChange[changes[i].ID]
+	}
+	return changes, nil
+}
+
+// SetHistoryFields writes recorded values back to an article, keyed by
+// their history field names.
+func (s *service) SetHistoryFields(id int, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	sets := make([]string, 0, len(values))
+	args := make([]any, 0, len(values)+1)
+	for field, value := range values {
+		column, ok := historyColumns[field]
+		if !ok {
+			return fmt.Errorf("field %s can't be restored", field)
+		}
+		sets = append(sets, column+" = ?")
+		// an unrated article is recorded with an empty rating
+		if field == "rating" && value == "" {
+			args = append(args, nil)
+			continue
+		}
+		args = append(args, value)
+	}
+	args = append(args, id)
+
+	query := fmt.Sprintf(`update articles set %s where id = ?;`, strings.Join(sets, ", "))
+	res, err := s.db.Exec(query, args...)
+	if isUniqueViolation(err) {
+		return ErrArticleExists
+	}
+	if err != nil {
+		return fmt.Errorf("error restoring article fields: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	return nil
+}
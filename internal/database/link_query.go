@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+	"reading-list-api/internal/types"
+	"time"
+)
+
+// AddArticleLink attaches an alternate link to an article. It returns
+// ErrArticleExists when the link is already saved, as any article's link or
+// alternate, and ErrArticleNotFound when the article doesn't exist.
+func (s *service) AddArticleLink(link *types.ArticleLink) error {
+	exists, err := s.ArticleExists(link.Link)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrArticleExists
+	}
+
+	if link.CreatedAt == "" {
+		link.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	query := `
+		insert into article_links (article_id, link, label, created_at)
+		select :article_id, :link, :label, :created_at
+		where exists (select 1 from articles where id = :article_id);
+	`
+	res, err := s.db.NamedExec(query, link)
+	if isUniqueViolation(err) {
+		return ErrArticleExists
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting article link: %v", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrArticleNotFound
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	link.ID = int(id)
+	return nil
+}
+
+func (s *service) GetArticleLinks(articleID int) ([]types.ArticleLink, error) {
+	links := make([]types.ArticleLink, 0)
+	query := `select * from article_links where article_id = ? order by id;`
+	err := s.db.Select(&links, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
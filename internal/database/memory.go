@@ -0,0 +1,848 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reading-list-api/internal/types"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Store = (*Memory)(nil)
+
+// Memory is a Store that keeps articles in a map, for exercising article
+// handling without a database file. It mirrors the SQLite queries: the same
+// defaults on insert, the same listing filters and order, and the same
+// ErrArticleNotFound and ErrArticleExists errors.
+type Memory struct {
+	mu       sync.Mutex
+	articles map[int]types.Article
+	nextID   int
+	// tags are each article's tags, sorted by name
+	tags map[int][]string
+	// changes are the recorded edits, oldest first
+	changes []types.ArticleChange
+	version uint64
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		articles: make(map[int]types.Article),
+		nextID:   1,
+		tags:     make(map[int][]string),
+	}
+}
+
+// sorted returns copies of the articles matching keep, in listing order.
+// Callers hold m.mu.
+func (m *Memory) sorted(order ArticleOrder, keep func(*types.Article) bool) []types.Article {
+	articles := make([]types.Article, 0, len(m.articles))
+	for _, article := range m.articles {
+		if keep(&article) {
+			articles = append(articles, article)
+		}
+	}
+	slices.SortFunc(articles, order.compare)
+	return articles
+}
+
+// compare orders two articles the way orderBy does.
+func (o ArticleOrder) compare(a, b types.Article) int {
+	if a.Pinned != b.Pinned {
+		if a.Pinned {
+			return -1
+		}
+		return 1
+	}
+	if c := cmp.Compare(a.SortOrder, b.SortOrder); c != 0 {
+		return c
+	}
+	var c int
+	switch o.Sort {
+	case "createdAt":
+		c = strings.Compare(a.CreatedAt, b.CreatedAt)
+	case "datePublished":
+		c = strings.Compare(a.DatePublished, b.DatePublished)
+	case "title":
+		c = strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+	case "id":
+	default:
+		c = strings.Compare(a.DateRead, b.DateRead)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if !o.Ascending {
+		c = -c
+	}
+	return c
+}
+
+// matching returns filter.matches for the articles in m. Callers hold m.mu.
+func (m *Memory) matching(filter ArticleFilter) func(*types.Article) bool {
+	return func(article *types.Article) bool {
+		return filter.matches(article, m.tags[article.ID])
+	}
+}
+
+// matches applies the filter the way where does, to an article with tags.
+func (f ArticleFilter) matches(article *types.Article, tags []string) bool {
+	switch {
+	case f.AnyExtraction:
+	case f.ExtractionStatus == "":
+		if !isListed(article) {
+			return false
+		}
+	case article.ExtractionStatus != f.ExtractionStatus:
+		return false
+	}
+	if f.Status != "" && article.Status != f.Status {
+		return false
+	}
+	if f.MaxID > 0 && article.ID > f.MaxID {
+		return false
+	}
+	if f.Site != "" && !strings.EqualFold(article.SiteName, f.Site) && !linkOnSite(article.Link, f.Site) {
+		return false
+	}
+	if f.InProgress && (article.Progress < 1 || article.Progress > 99) {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(article.Author), strings.ToLower(f.Author)) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, article.Type) {
+		return false
+	}
+	return true
+}
+
+// linkOnSite mirrors the link patterns of the Site filter.
+func linkOnSite(link string, site string) bool {
+	for _, scheme := range []string{"http://", "https://"} {
+		for _, host := range []string{site, "www." + site} {
+			if strings.HasPrefix(strings.ToLower(link), scheme+strings.ToLower(host)+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isListed mirrors listedCondition.
+func isListed(article *types.Article) bool {
+	return article.ExtractionStatus == types.ExtractionComplete || article.Extracted
+}
+
+func (m *Memory) GetAllArticles(order ArticleOrder) (*[]types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	articles := m.sorted(order, isListed)
+	return &articles, nil
+}
+
+func (m *Memory) EachArticle(ctx context.Context, order ArticleOrder, fn func(*types.Article) error) error {
+	m.mu.Lock()
+	articles := m.sorted(order, isListed)
+	m.mu.Unlock()
+
+	for i := range articles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&articles[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetArticlePage(filter ArticleFilter, offset int, limit int) (*[]types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	articles := m.sorted(filter.Order, m.matching(filter))
+	offset = min(max(offset, 0), len(articles))
+	end := len(articles)
+	if limit >= 0 {
+		end = min(offset+limit, end)
+	}
+	page := slices.Clone(articles[offset:end])
+	return &page, nil
+}
+
+func (m *Memory) GetArticlePageAfterCursor(filter ArticleFilter, after *ArticleCursor, limit int) (*[]types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	articles := m.sorted(filter.Order, m.matching(filter))
+	if after != nil {
+		// the cursor stands in for the last article of the previous page
+		last := types.Article{
+			ID:        after.ID,
+			Pinned:    after.Pinned,
+			SortOrder: after.SortOrder,
+		}
+		switch after.Order.Sort {
+		case "createdAt":
+			last.CreatedAt = after.Value
+		case "datePublished":
+			last.DatePublished = after.Value
+		case "title":
+			last.Title = after.Value
+		case "id":
+		default:
+			last.DateRead = after.Value
+		}
+		start, _ := slices.BinarySearchFunc(articles, last, filter.Order.compare)
+		for start < len(articles) && filter.Order.compare(articles[start], last) <= 0 {
+			start++
+		}
+		articles = articles[start:]
+	}
+	if limit >= 0 {
+		articles = articles[:min(limit, len(articles))]
+	}
+	page := slices.Clone(articles)
+	return &page, nil
+}
+
+func (m *Memory) GetArticleCount(filter ArticleFilter) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	count := 0
+	keep := m.matching(filter)
+	for _, article := range m.articles {
+		if keep(&article) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *Memory) GetMaxArticleID() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	maxID := 0
+	for id := range m.articles {
+		maxID = max(maxID, id)
+	}
+	return maxID, nil
+}
+
+// byLink finds the article saved under link. Memory keeps no alternate
+// links, so only the link itself matches. Callers hold m.mu.
+func (m *Memory) byLink(link string) (types.Article, bool) {
+	for _, article := range m.articles {
+		if article.Link == link {
+			return article, true
+		}
+	}
+	return types.Article{}, false
+}
+
+func (m *Memory) ArticleExists(link string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.byLink(link)
+	return ok, nil
+}
+
+// inserted is the row an insert of article stores: the columns of
+// insertArticleQuery, with the rest left at their defaults.
+func inserted(article *types.Article) types.Article {
+	stored := *article
+	stored.Pinned = false
+	stored.SortOrder = 0
+	stored.ArchiveURL = ""
+	stored.LinkStatus = ""
+	stored.LastChecked = ""
+	stored.ExtractionError = ""
+	stored.ExtractionAttempts = 0
+	stored.Embedding = nil
+	stored.Progress = 0
+	return stored
+}
+
+func (m *Memory) InsertArticle(article *types.Article) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.byLink(article.Link); ok {
+		return ErrArticleExists
+	}
+	prepareInsert(article)
+	article.ID = m.nextID
+	m.nextID++
+	m.articles[article.ID] = inserted(article)
+	m.version++
+	return nil
+}
+
+func (m *Memory) UpsertArticle(article *types.Article) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prepareInsert(article)
+	stored, ok := m.byLink(article.Link)
+	if !ok {
+		article.ID = m.nextID
+		m.nextID++
+		stored = inserted(article)
+		// the upsert leaves the attempt cap out of its columns
+		stored.ExtractionMaxAttempts = 0
+		m.articles[article.ID] = stored
+		m.version++
+		return nil
+	}
+
+	// refresh what extraction produces, as the on conflict clause does
+	stored.OriginalLink = article.OriginalLink
+	stored.Title = article.Title
+	stored.Author = article.Author
+	stored.Summary = article.Summary
+	stored.DatePublished = article.DatePublished
+	stored.Type = article.Type
+	stored.PaperID = article.PaperID
+	stored.ExtractionStatus = article.ExtractionStatus
+	stored.Extracted = stored.Extracted || article.Extracted
+	stored.Extractor = article.Extractor
+	stored.TypeUncertain = article.TypeUncertain
+	stored.AuthorGuessed = article.AuthorGuessed
+	stored.TitleGuessed = article.TitleGuessed
+	stored.SiteName = article.SiteName
+	stored.SuggestedTags = article.SuggestedTags
+	stored.Content = article.Content
+	if stored.ImagePath == "" {
+		stored.ImagePath = article.ImagePath
+	}
+	m.articles[stored.ID] = stored
+	article.ID = stored.ID
+	m.version++
+	return nil
+}
+
+func (m *Memory) GetArticleByID(id int) (*types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok {
+		return nil, ErrArticleNotFound
+	}
+	return &article, nil
+}
+
+func (m *Memory) GetArticlesByIDs(ids []int) (*[]types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	articles := make([]types.Article, 0, len(ids))
+	for _, id := range ids {
+		if article, ok := m.articles[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+	return &articles, nil
+}
+
+func (m *Memory) GetArticleByLink(link string) (*types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.byLink(link)
+	if !ok {
+		return nil, ErrArticleNotFound
+	}
+	return &article, nil
+}
+
+func (m *Memory) GetArticleByPaperID(paperID string) (*types.Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, article := range m.articles {
+		if article.PaperID == paperID {
+			return &article, nil
+		}
+	}
+	return nil, ErrArticleNotFound
+}
+
+// update applies edit to a stored article, ErrArticleNotFound without one.
+func (m *Memory) update(id int, edit func(*types.Article)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok {
+		return ErrArticleNotFound
+	}
+	edit(&article)
+	m.articles[id] = article
+	m.version++
+	return nil
+}
+
+func (m *Memory) TogglePinned(id int, sortOrder *int) error {
+	return m.update(id, func(article *types.Article) {
+		article.Pinned = !article.Pinned
+		if sortOrder != nil {
+			article.SortOrder = *sortOrder
+		}
+	})
+}
+
+// setStatus changes an article's reading status the way setStatusQuery
+// does.
+func setStatus(article *types.Article, status string, now time.Time) {
+	now = now.UTC()
+	article.Status = status
+	switch {
+	case status != types.StatusRead:
+		article.CompletedAt = ""
+	case article.CompletedAt == "":
+		article.CompletedAt = now.Format(time.RFC3339)
+	}
+	if status == types.StatusRead && article.DateRead == "" {
+		article.DateRead = now.Format("2006-01-02")
+	}
+}
+
+func (m *Memory) SetStatus(id int, status string) error {
+	now := time.Now()
+	return m.update(id, func(article *types.Article) {
+		setStatus(article, status, now)
+	})
+}
+
+func (m *Memory) SetStatuses(ids []int, status string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	updated := 0
+	for _, id := range ids {
+		article, ok := m.articles[id]
+		if !ok {
+			continue
+		}
+		setStatus(&article, status, now)
+		m.articles[id] = article
+		updated++
+	}
+	m.version++
+	return updated, nil
+}
+
+func (m *Memory) SetProgress(id int, progress int) error {
+	return m.update(id, func(article *types.Article) {
+		article.Progress = progress
+		if progress > 0 && article.Status == types.StatusUnread {
+			article.Status = types.StatusReading
+		}
+	})
+}
+
+func (m *Memory) SetImagePath(id int, imagePath string) error {
+	return m.update(id, func(article *types.Article) {
+		article.ImagePath = imagePath
+	})
+}
+
+func (m *Memory) SetArchiveURL(id int, archiveURL string) error {
+	err := m.update(id, func(article *types.Article) {
+		article.ArchiveURL = archiveURL
+	})
+	// the update doesn't check that the article exists
+	if err == ErrArticleNotFound {
+		return nil
+	}
+	return err
+}
+
+// setColumn stores value, as it is written to column, on article. Numbers
+// are parsed the way SQLite's integer columns take them, and an empty
+// rating clears it.
+func setColumn(article *types.Article, column string, value string) error {
+	var err error
+	switch column {
+	case "title":
+		article.Title = value
+	case "author":
+		article.Author = value
+	case "summary":
+		article.Summary = value
+	case "date_published":
+		article.DatePublished = value
+	case "link":
+		article.Link = value
+	case "img_path":
+		article.ImagePath = value
+	case "type":
+		article.Type, err = strconv.Atoi(value)
+	case "status":
+		article.Status = value
+	case "completed_at":
+		article.CompletedAt = value
+	case "progress":
+		article.Progress, err = strconv.Atoi(value)
+	case "pinned":
+		article.Pinned = value == "1"
+	case "sort_order":
+		article.SortOrder, err = strconv.Atoi(value)
+	case "site_name":
+		article.SiteName = value
+	case "rating":
+		article.Rating = nil
+		if value != "" {
+			var rating int
+			rating, err = strconv.Atoi(value)
+			article.Rating = &rating
+		}
+	case "notes":
+		article.Notes = value
+	default:
+		return fmt.Errorf("unknown column %s", column)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %v", column, value, err)
+	}
+	return nil
+}
+
+func (m *Memory) PatchArticle(id int, fields map[string]any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok {
+		return ErrArticleNotFound
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	for field, value := range fields {
+		column, ok := PatchColumns[field]
+		if !ok {
+			return fmt.Errorf("field %s can't be edited", field)
+		}
+		text := ""
+		if value != nil {
+			text = fmt.Sprint(value)
+		}
+		if err := setColumn(&article, column, text); err != nil {
+			return fmt.Errorf("error editing article: %v", err)
+		}
+		switch field {
+		case "title":
+			article.TitleGuessed = false
+		case "author":
+			article.AuthorGuessed = false
+		case "type":
+			article.TypeUncertain = false
+		}
+	}
+	m.articles[id] = article
+	m.version++
+	return nil
+}
+
+func (m *Memory) InsertArticlesTx(ctx context.Context, articles []*types.Article, tags [][]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// check the whole batch first, as nothing is stored if any insert fails
+	links := make(map[string]bool, len(articles))
+	for _, article := range articles {
+		if _, ok := m.byLink(article.Link); ok || links[article.Link] {
+			return fmt.Errorf("%w: %s", ErrArticleExists, article.Link)
+		}
+		links[article.Link] = true
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	for _, article := range articles {
+		prepareInsert(article)
+		article.ID = m.nextID
+		m.nextID++
+		m.articles[article.ID] = inserted(article)
+	}
+	for i := range tags {
+		m.addTags(articles[i].ID, tags[i])
+	}
+	m.version++
+	return nil
+}
+
+func (m *Memory) DeleteArticle(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.articles[id]; !ok {
+		return ErrArticleNotFound
+	}
+	delete(m.articles, id)
+	// tags and history go with the article, as they cascade in SQLite
+	delete(m.tags, id)
+	m.changes = slices.DeleteFunc(m.changes, func(change types.ArticleChange) bool {
+		return change.ArticleID == id
+	})
+	m.version++
+	return nil
+}
+
+func (m *Memory) QueueExtraction(id int, maxAttempts int) error {
+	return m.update(id, func(article *types.Article) {
+		article.ExtractionStatus = types.ExtractionPending
+		article.ExtractionError = ""
+		article.ExtractionAttempts = 0
+		article.ExtractionMaxAttempts = maxAttempts
+	})
+}
+
+func (m *Memory) ClaimExtraction(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok || article.ExtractionStatus != types.ExtractionPending {
+		return false, nil
+	}
+	article.ExtractionStatus = types.ExtractionProcessing
+	article.ExtractionAttempts++
+	m.articles[id] = article
+	m.version++
+	return true, nil
+}
+
+func (m *Memory) CompleteExtraction(extracted *types.Article) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[extracted.ID]
+	if !ok {
+		return ErrArticleNotFound
+	}
+	if other, ok := m.byLink(extracted.Link); ok && other.ID != extracted.ID {
+		return ErrArticleExists
+	}
+	article.Title = extracted.Title
+	article.Author = extracted.Author
+	article.Summary = extracted.Summary
+	article.DatePublished = extracted.DatePublished
+	article.Link = extracted.Link
+	article.Type = extracted.Type
+	article.PaperID = extracted.PaperID
+	article.Extractor = extracted.Extractor
+	article.TypeUncertain = extracted.TypeUncertain
+	article.AuthorGuessed = extracted.AuthorGuessed
+	article.TitleGuessed = extracted.TitleGuessed
+	article.SiteName = extracted.SiteName
+	article.SuggestedTags = extracted.SuggestedTags
+	article.Content = extracted.Content
+	if article.ImagePath == "" {
+		article.ImagePath = extracted.ImagePath
+	}
+	article.ExtractionStatus = types.ExtractionComplete
+	article.ExtractionError = ""
+	article.Extracted = true
+	m.articles[article.ID] = article
+	m.version++
+	return nil
+}
+
+func (m *Memory) FailExtraction(id int, reason string, retry bool) error {
+	return m.update(id, func(article *types.Article) {
+		switch {
+		case retry:
+			article.ExtractionStatus = types.ExtractionPending
+		case article.Title != "":
+			article.ExtractionStatus = types.ExtractionComplete
+		default:
+			article.ExtractionStatus = types.ExtractionFailed
+		}
+		article.ExtractionError = reason
+	})
+}
+
+func (m *Memory) RetryExtraction(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok || article.ExtractionStatus != types.ExtractionFailed {
+		return ErrArticleNotFound
+	}
+	article.ExtractionStatus = types.ExtractionPending
+	article.ExtractionAttempts = 0
+	m.articles[id] = article
+	m.version++
+	return nil
+}
+
+func (m *Memory) GetPendingExtractionIDs() ([]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]int, 0)
+	for id, article := range m.articles {
+		if article.ExtractionStatus == types.ExtractionPending {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (m *Memory) RequeueInterruptedExtractions() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, article := range m.articles {
+		if article.ExtractionStatus == types.ExtractionProcessing {
+			article.ExtractionStatus = types.ExtractionPending
+			m.articles[id] = article
+			m.version++
+		}
+	}
+	return nil
+}
+
+func (m *Memory) AddTags(articleID int, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addTags(articleID, tags)
+	return nil
+}
+
+// addTags adds tags to an article, keeping them sorted. Callers hold m.mu.
+func (m *Memory) addTags(articleID int, tags []string) {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return
+	}
+	have := m.tags[articleID]
+	for _, tag := range tags {
+		if !slices.Contains(have, tag) {
+			have = append(have, tag)
+		}
+	}
+	slices.Sort(have)
+	m.tags[articleID] = have
+	m.version++
+}
+
+func (m *Memory) RemoveTag(articleID int, tag string) error {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	have := m.tags[articleID]
+	i := slices.Index(have, tag)
+	if i < 0 {
+		return ErrTagNotFound
+	}
+	m.tags[articleID] = slices.Delete(slices.Clone(have), i, i+1)
+	m.version++
+	return nil
+}
+
+func (m *Memory) GetTagsForArticle(articleID int) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := make([]string, 0)
+	return append(tags, m.tags[articleID]...), nil
+}
+
+func (m *Memory) GetTagsByArticle() (map[int][]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := make(map[int][]string, len(m.tags))
+	for id, have := range m.tags {
+		if len(have) > 0 {
+			tags[id] = slices.Clone(have)
+		}
+	}
+	return tags, nil
+}
+
+func (m *Memory) GetAllTags() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := make([]string, 0)
+	for _, have := range m.tags {
+		for _, tag := range have {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags, nil
+}
+
+func (m *Memory) RecordChange(change *types.ArticleChange) error {
+	if len(change.Fields) == 0 {
+		return nil
+	}
+	if change.ChangedAt == "" {
+		change.ChangedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	change.ID = 1
+	if n := len(m.changes); n > 0 {
+		change.ID = m.changes[n-1].ID + 1
+	}
+	for i := range change.Fields {
+		change.Fields[i].ChangeID = change.ID
+	}
+	stored := *change
+	stored.Fields = slices.Clone(change.Fields)
+	m.changes = append(m.changes, stored)
+	return nil
+}
+
+func (m *Memory) GetArticleHistory(articleID int) ([]types.ArticleChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	changes := make([]types.ArticleChange, 0)
+	for i := len(m.changes) - 1; i >= 0; i-- {
+		if m.changes[i].ArticleID != articleID {
+			continue
+		}
+		change := m.changes[i]
+		change.Fields = slices.Clone(change.Fields)
+		slices.SortFunc(change.Fields, func(a, b types.FieldChange) int {
+			return strings.Compare(a.Field, b.Field)
+		})
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (m *Memory) SetHistoryFields(id int, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	article, ok := m.articles[id]
+	if !ok {
+		return ErrArticleNotFound
+	}
+	for field, value := range values {
+		column, ok := historyColumns[field]
+		if !ok {
+			return fmt.Errorf("field %s can't be restored", field)
+		}
+		if err := setColumn(&article, column, value); err != nil {
+			return fmt.Errorf("error restoring article fields: %v", err)
+		}
+	}
+	if other, ok := m.byLink(article.Link); ok && other.ID != id {
+		return ErrArticleExists
+	}
+	m.articles[id] = article
+	m.version++
+	return nil
+}
+
+func (m *Memory) DataVersion() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.version
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reading-list-api/internal/types"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMemoryListsLikeSQLite runs the same listings on a Memory and a SQLite
+// store holding the same articles and checks they agree.
+func TestMemoryListsLikeSQLite(t *testing.T) {
+	stores := []Store{newTestService(t), NewMemory()}
+	articles := []types.Article{
+		{Title: "Beta", Author: "Ada Lovelace", DateRead: "2024-05-02", Link: "https://www.example.com/b"},
+		{Title: "alpha", Author: "Alan Turing", DateRead: "2024-05-01", Link: "http://example.com/a", Status: types.StatusRead},
+		{Title: "Gamma", DateRead: "2024-05-01", Link: "https://other.org/g", SiteName: "Example", Type: types.TypePaper},
+		{Title: "Delta", DateRead: "2024-05-03", Link: "https://other.org/d"},
+		{Title: "Pending", DateRead: "2024-05-04", Link: "https://other.org/p", ExtractionStatus: types.ExtractionPending},
+	}
+	tags := map[int][]string{1: {"go", "db"}, 2: {"go"}}
+	for _, store := range stores {
+		for i := range articles {
+			article := articles[i]
+			if err := store.InsertArticle(&article); err != nil {
+				t.Fatalf("InsertArticle: %v", err)
+			}
+			if err := store.AddTags(article.ID, tags[article.ID]); err != nil {
+				t.Fatalf("AddTags: %v", err)
+			}
+		}
+		if err := store.TogglePinned(1, nil); err != nil {
+			t.Fatalf("TogglePinned: %v", err)
+		}
+		if err := store.SetProgress(4, 40); err != nil {
+			t.Fatalf("SetProgress: %v", err)
+		}
+	}
+
+	filters := []ArticleFilter{
+		{},
+		{Order: ArticleOrder{Sort: "title", Ascending: true}},
+		{Order: ArticleOrder{Sort: "id"}},
+		{Status: types.StatusRead},
+		{ExtractionStatus: types.ExtractionPending},
+		{AnyExtraction: true},
+		{MaxID: 3},
+		{Site: "example.com"},
+		{InProgress: true},
+		{Types: []int{types.TypePaper}},
+		{Author: "ada"},
+		{Tags: []string{"go"}},
+		{Tags: []string{"go", "db"}},
+	}
+	for _, filter := range filters {
+		t.Run(fmt.Sprintf("%+v", filter), func(t *testing.T) {
+			var listed [][]int
+			for _, store := range stores {
+				page, err := store.GetArticlePage(filter, 0, 10)
+				if err != nil {
+					t.Fatalf("GetArticlePage: %v", err)
+				}
+				count, err := store.GetArticleCount(filter)
+				if err != nil {
+					t.Fatalf("GetArticleCount: %v", err)
+				}
+				if count != len(*page) {
+					t.Errorf("%T counts %d articles but lists %d", store, count, len(*page))
+				}
+				listed = append(listed, articleIDs(*page))
+			}
+			if !slices.Equal(listed[0], listed[1]) {
+				t.Errorf("SQLite lists %v, Memory lists %v", listed[0], listed[1])
+			}
+		})
+	}
+
+	t.Run("cursor", func(t *testing.T) {
+		for _, order := range []ArticleOrder{{}, {Sort: "title", Ascending: true}, {Sort: "id"}} {
+			var listed [][]int
+			for _, store := range stores {
+				var ids []int
+				var after *ArticleCursor
+				for {
+					page, err := store.GetArticlePageAfterCursor(ArticleFilter{Order: order}, after, 2)
+					if err != nil {
+						t.Fatalf("GetArticlePageAfterCursor: %v", err)
+					}
+					if len(*page) == 0 {
+						break
+					}
+					ids = append(ids, articleIDs(*page)...)
+					cursor := CursorAfter(&(*page)[len(*page)-1], order)
+					after = &cursor
+				}
+				listed = append(listed, ids)
+			}
+			if !slices.Equal(listed[0], listed[1]) {
+				t.Errorf("%+v: SQLite pages through %v, Memory through %v", order, listed[0], listed[1])
+			}
+		}
+	})
+}
+
+// TestMemoryEditsLikeSQLite makes the same edits on a Memory and a SQLite
+// store and checks the articles end up the same.
+func TestMemoryEditsLikeSQLite(t *testing.T) {
+	stores := []Store{newTestService(t), NewMemory()}
+	for _, store := range stores {
+		batch := []*types.Article{
+			{Title: "a", TitleGuessed: true, Link: "https://example.com/a"},
+			{Title: "b", AuthorGuessed: true, Link: "https://example.com/b"},
+			{Title: "c", Link: "https://example.com/c"},
+		}
+		if err := store.InsertArticlesTx(context.Background(), batch, [][]string{{"Go"}, nil, {"db", "go"}}); err != nil {
+			t.Fatalf("%T InsertArticlesTx: %v", store, err)
+		}
+		duplicate := []*types.Article{{Title: "d", Link: "https://example.com/d"}, {Title: "a", Link: "https://example.com/a"}}
+		if err := store.InsertArticlesTx(context.Background(), duplicate, nil); !errors.Is(err, ErrArticleExists) {
+			t.Errorf("%T InsertArticlesTx with a saved link = %v, want ErrArticleExists", store, err)
+		}
+
+		if err := store.PatchArticle(1, map[string]any{"title": "A", "type": types.TypePaper, "rating": 4, "notes": "n"}); err != nil {
+			t.Fatalf("%T PatchArticle: %v", store, err)
+		}
+		if err := store.PatchArticle(2, map[string]any{"author": "", "rating": nil}); err != nil {
+			t.Fatalf("%T PatchArticle: %v", store, err)
+		}
+		if err := store.PatchArticle(99, map[string]any{"notes": "n"}); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("%T PatchArticle of a missing article = %v, want ErrArticleNotFound", store, err)
+		}
+		if updated, err := store.SetStatuses([]int{1, 2, 99}, types.StatusRead); err != nil || updated != 2 {
+			t.Errorf("%T SetStatuses = %d, %v, want 2 updated", store, updated, err)
+		}
+		if err := store.SetHistoryFields(3, map[string]string{"link": "https://example.com/a"}); !errors.Is(err, ErrArticleExists) {
+			t.Errorf("%T SetHistoryFields onto a saved link = %v, want ErrArticleExists", store, err)
+		}
+		restore := map[string]string{"pinned": "1", "sortOrder": "2", "rating": "3", "progress": "50", "siteName": "Example"}
+		if err := store.SetHistoryFields(3, restore); err != nil {
+			t.Fatalf("%T SetHistoryFields: %v", store, err)
+		}
+		if err := store.SetArchiveURL(2, "https://web.archive.org/b"); err != nil {
+			t.Fatalf("%T SetArchiveURL: %v", store, err)
+		}
+	}
+
+	for id := 1; id <= 4; id++ {
+		var saved []map[string]string
+		for _, store := range stores {
+			article, err := store.GetArticleByID(id)
+			if errors.Is(err, ErrArticleNotFound) {
+				saved = append(saved, nil)
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%T GetArticleByID: %v", store, err)
+			}
+			tags, err := store.GetTagsForArticle(id)
+			if err != nil {
+				t.Fatalf("%T GetTagsForArticle: %v", store, err)
+			}
+			values := HistoryValues(article)
+			// the stamps are the time of the edit, which can differ
+			values["completedAt"] = fmt.Sprint(article.CompletedAt != "")
+			values["archiveUrl"] = article.ArchiveURL
+			values["guessed"] = fmt.Sprint(article.TitleGuessed, article.AuthorGuessed)
+			values["tags"] = strings.Join(tags, ",")
+			saved = append(saved, values)
+		}
+		if !maps.Equal(saved[0], saved[1]) {
+			t.Errorf("article %d: SQLite has %v, Memory has %v", id, saved[0], saved[1])
+		}
+	}
+}
+
+func TestMemoryDeleteArticleDropsTags(t *testing.T) {
+	m := NewMemory()
+	article := &types.Article{Title: "a", Link: "https://example.com/a"}
+	if err := m.InsertArticle(article); err != nil {
+		t.Fatalf("InsertArticle: %v", err)
+	}
+	if err := m.AddTags(article.ID, []string{"Go"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := m.DeleteArticle(article.ID); err != nil {
+		t.Fatalf("DeleteArticle: %v", err)
+	}
+	if tags, _ := m.GetAllTags(); len(tags) != 0 {
+		t.Errorf("tags %v outlived their article", tags)
+	}
+}
+
+func articleIDs(articles []types.Article) []int {
+	ids := make([]int, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	return ids
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"reading-list-api/internal/types"
+	"strings"
+)
+
+// searchIndexSchema is the FTS5 index over article titles, summaries and
+// authors. It reads its text from articles, and the triggers keep it in step
+// with every insert, update and delete.
+const searchIndexSchema = `
+	create virtual table if not exists articles_fts using fts5(
+		title, summary, author,
+		content = 'articles', content_rowid = 'id'
+	);
+	create trigger if not exists articles_fts_insert after insert on articles begin
+		insert into articles_fts (rowid, title, summary, author) values (new.id, new.title, new.summary, new.author);
+	end;
+	create trigger if not exists articles_fts_delete after delete on articles begin
+		insert into articles_fts (articles_fts, rowid, title, summary, author) values ('delete', old.id, old.title, old.summary, old.author);
+	end;
+	create trigger if not exists articles_fts_update after update of title, summary, author on articles begin
+		insert into articles_fts (articles_fts, rowid, title, summary, author) values ('delete', old.id, old.title, old.summary, old.author);
+		insert into articles_fts (rowid, title, summary, author) values (new.id, new.title, new.summary, new.author);
+	end;
+`
+
+// dropSearchTriggers detaches the index, whose triggers would fail every
+// write to articles in a build without FTS5.
+const dropSearchTriggers = `
+	drop trigger if exists articles_fts_insert;
+	drop trigger if exists articles_fts_delete;
+	drop trigger if exists articles_fts_update;
+`
+
+// setupSearchIndex creates the full-text index and reports whether it can be
+// used. SQLite only has FTS5 when built with the sqlite_fts5 tag; without it
+// search falls back to like. An index that missed writes while detached is
+// rebuilt from articles.
+func (s *service) setupSearchIndex() bool {
+	var attached int
+	err := s.db.QueryRow(`select count(*) from sqlite_master where type = 'trigger' and name = 'articles_fts_insert';`).Scan(&attached)
+	if err != nil {
+		log.Printf("error inspecting the search index: %v", err)
+		return false
+	}
+
+	// an index created by an FTS5 build is still in the schema of a build
+	// without it, so reading from the index is what tells
+	_, err = s.db.Exec(searchIndexSchema)
+	if err == nil {
+		_, err = s.db.Exec(`select rowid from articles_fts limit 0;`)
+	}
+	if err != nil {
+		log.Printf("full-text search unavailable, searching with like instead: %v", err)
+		if _, err := s.db.Exec(dropSearchTriggers); err != nil {
+			log.Printf("error detaching the search index: %v", err)
+		}
+		return false
+	}
+	if attached == 0 {
+		if _, err := s.db.Exec(`insert into articles_fts (articles_fts) values ('rebuild');`); err != nil {
+			log.Printf("error building the search index: %v", err)
+			return false
+		}
+	}
+	return true
+}
+
+// searchTerms splits a search into its words.
+func searchTerms(query string) []string {
+	return strings.Fields(query)
+}
+
+// ftsMatch turns the words of a search into an FTS5 query matching articles
+// that have every word, the last one also as a prefix. Each word is quoted so
+// FTS5 operators in it are taken literally.
+func ftsMatch(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	quoted[len(quoted)-1] += "*"
+	return strings.Join(quoted, " ")
+}
+
+// searchWhere builds the condition and arguments of a search over listed
+// articles: an FTS5 match when the index is there, otherwise every word must
+// appear in the title, summary or author, ignoring case.
+func (s *service) searchWhere(terms []string) (string, []any) {
+	clauses := []string{listedCondition("a")}
+	var args []any
+	if s.fts {
+		clauses = append(clauses, "articles_fts match ?")
+		args = append(args, ftsMatch(terms))
+		return strings.Join(clauses, " and "), args
+	}
+	for _, term := range terms {
+		clauses = append(clauses, `(a.title like ? escape '\' or a.summary like ? escape '\' or a.author like ? escape '\')`)
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		args = append(args, pattern, pattern, pattern)
+	}
+	return strings.Join(clauses, " and "), args
+}
+
+func (s *service) searchFrom() string {
+	if s.fts {
+		return "articles a join articles_fts on articles_fts.rowid = a.id"
+	}
+	return "articles a"
+}
+
+// SearchArticles returns a page of the complete articles whose title, summary
+// or author has every word of query, best match first with the full-text
+// index and newest first without it.
+func (s *service) SearchArticles(query string, offset int, limit int) (*[]types.Article, error) {
+	articles := make([]types.Article, 0)
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return &articles, nil
+	}
+	where, args := s.searchWhere(terms)
+	orderBy := "order by a.date_read desc, a.id desc"
+	if s.fts {
+		orderBy = "order by articles_fts.rank, a.id desc"
+	}
+	q := fmt.Sprintf(`
+		select %s from %s
+		where %s
+		%s
+		limit ?
+		offset ?;
+	`, selectList("a"), s.searchFrom(), where, orderBy)
+
+	err := s.db.Select(&articles, q, append(args, limit, offset)...)
+	if err != nil {
+		log.Println("error searching articles", err)
+		return nil, err
+	}
+	return &articles, nil
+}
+
+// GetSearchCount counts the articles SearchArticles pages through.
+func (s *service) GetSearchCount(query string) (int, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return 0, nil
+	}
+	var count int
+	where, args := s.searchWhere(terms)
+	q := fmt.Sprintf(`select count(*) from %s where %s;`, s.searchFrom(), where)
+	err := s.db.QueryRow(q, args...).Scan(&count)
+	if err != nil {
+		log.Println("error counting search results", err)
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrTagNotFound is returned when an article doesn't have the tag asked for.
+var ErrTagNotFound = errors.New("tag not found")
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// AddTags attaches tags to an article, creating any that don't exist yet.
+// Tags the article already has are left alone.
+func (s *service) AddTags(articleID int, tags []string) error {
+	tags = normalizeTags(tags)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addTags(tx, articleID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addTags(tx *sqlx.Tx, articleID int, tags []string) error {
+	for _, tag := range normalizeTags(tags) {
+		if _, err := tx.Exec(`insert or ignore into tags (name) values (?);`, tag); err != nil {
+			return err
+		}
+		query := `insert or ignore into article_tags (article_id, tag_id) select ?, id from tags where name = ?;`
+		if _, err := tx.Exec(query, articleID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveTag detaches a tag from an article, ErrTagNotFound when the article
+// doesn't have it. The tag itself stays for other articles.
+func (s *service) RemoveTag(articleID int, tag string) error {
+	query := `delete from article_tags where article_id = ? and tag_id = (select id from tags where name = ?);`
+	res, err := s.db.Exec(query, articleID, strings.ToLower(strings.TrimSpace(tag)))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// GetAllTags lists every tag name in use, alphabetically.
+func (s *service) GetAllTags() ([]string, error) {
+	tags := make([]string, 0)
+	err := s.db.Select(&tags, `select name from tags order by name;`)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *service) GetTagsForArticle(articleID int) ([]string, error) {
+	tags := make([]string, 0)
+	query := `
+		select t.name from tags t
+		join article_tags at on at.tag_id = t.id
+		where at.article_id = ?
+		order by t.name;
+	`
+	err := s.db.Select(&tags, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetTagsByArticle maps the id of every tagged article to its tags, sorted
+// by name, so exports can tag each row without a query per article.
+func (s *service) GetTagsByArticle() (map[int][]string, error) {
+	var rows []struct {
+		ArticleID int    `db:"article_id"`
+		Name      string `db:"name"`
+	}
+	query := `
+		select at.article_id, t.name from tags t
+		join article_tags at on at.tag_id = t.id
+		order by at.article_id, t.name;
+	`
+	if err := s.db.Select(&rows, query); err != nil {
+		return nil, err
+	}
+	tags := make(map[int][]string)
+	for _, row := range rows {
+		tags[row.ArticleID] = append(tags[row.ArticleID], row.Name)
+	}
+	return tags, nil
+}
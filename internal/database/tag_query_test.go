@@ -0,0 +1,31 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetTagsByArticle(t *testing.T) {
+	s := newTestService(t)
+	first := insertTestArticle(t, s, "first")
+	second := insertTestArticle(t, s, "second")
+	insertTestArticle(t, s, "untagged")
+	if err := s.AddTags(first.ID, []string{"go", "databases"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	if err := s.AddTags(second.ID, []string{"go"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+
+	tags, err := s.GetTagsByArticle()
+	if err != nil {
+		t.Fatalf("GetTagsByArticle: %v", err)
+	}
+	want := map[int][]string{
+		first.ID:  {"databases", "go"},
+		second.ID: {"go"},
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("GetTagsByArticle = %v, want %v", tags, want)
+	}
+}
@@ -0,0 +1,196 @@
+// Package embed is a small client for OpenAI-compatible /embeddings
+// endpoints, such as OpenRouter's, plus helpers to store and compare the
+// vectors it returns.
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultBaseURL = "https://openrouter.ai/api/v1"
+const defaultTimeout = 30 * time.Second
+const defaultMaxResponseBytes = 20 << 20 // 20MB, vectors are verbose as JSON
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's
+// MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
+type Client struct {
+	apiKey      string
+	baseURL     string
+	model       string
+	http        *http.Client
+	maxResponse int64
+}
+
+type ClientConfig struct {
+	APIKey string
+	Model  string
+	// Optional. Any endpoint serving the OpenAI embeddings API.
+	BaseURL string
+
+	// Optional. If set, used only when HTTPClient is nil.
+	Timeout time.Duration
+
+	HTTPClient *http.Client
+
+	// Optional. Largest response body read before giving up, 20MB by
+	// default.
+	MaxResponseBytes int64
+}
+
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("missing EMBEDDINGS_API_KEY")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("missing EMBEDDINGS_MODEL")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		hc = &http.Client{Timeout: timeout}
+	}
+
+	maxResponse := cfg.MaxResponseBytes
+	if maxResponse <= 0 {
+		maxResponse = defaultMaxResponseBytes
+	}
+
+	return &Client{
+		apiKey:      cfg.APIKey,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		model:       cfg.Model,
+		http:        hc,
+		maxResponse: maxResponse,
+	}, nil
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("embeddings api error: status=%d", e.StatusCode)
+}
+
+// Embed returns one vector per input text, in the same order.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("embeddings: no input provided")
+	}
+
+	b, err := json.Marshal(embeddingsRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/embeddings", bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("embeddings: read response: %w", err)
+	}
+	if int64(len(raw)) > c.maxResponse {
+		return nil, fmt.Errorf("embeddings: %w: over %d bytes", ErrResponseTooLarge, c.maxResponse)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(raw)}
+	}
+
+	var out embeddingsResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("embeddings: decode response: %w", err)
+	}
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings: got %d vectors for %d inputs", len(out.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings: unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Encode packs a vector as little-endian float32s for storage in a blob.
+func Encode(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+	}
+	return b
+}
+
+// Decode unpacks a vector stored by Encode.
+func Decode(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return v
+}
+
+// Cosine is the cosine similarity of two vectors, 0 when their lengths
+// differ, e.g. after switching models.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -4,19 +4,32 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"reading-list-api/internal/retry"
 	"time"
 )
 
 const defaultBaseURL = "https://api.exa.ai"
 const defaultTimeout = 30 * time.Second
+const defaultRetryBackoff = 500 * time.Millisecond
+const defaultMaxResponseBytes = 10 << 20 // 10MB
+
+// ErrResponseTooLarge is returned when a response body exceeds the client's
+// MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
 
 type Client struct {
-	apiKey  string
-	baseURL string
-	http    *http.Client
+	apiKey       string
+	baseURL      string
+	http         *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	maxResponse  int64
 }
 
 type ClientConfig struct {
@@ -27,6 +40,16 @@ type ClientConfig struct {
 	Timeout time.Duration
 
 	HTTPClient *http.Client
+
+	// Optional. Number of extra attempts made after a network error or a 5xx
+	// response. 4xx responses are never retried.
+	MaxRetries int
+	// Optional. Delay before the first retry; doubled for each retry after.
+	RetryBackoff time.Duration
+
+	// Optional. Largest response body read before giving up, 10MB by
+	// default.
+	MaxResponseBytes int64
 }
 
 func NewClient(cfg ClientConfig) (*Client, error) {
@@ -48,10 +71,23 @@ func NewClient(cfg ClientConfig) (*Client, error) {
 		hc = &http.Client{Timeout: timeout}
 	}
 
+	backoff := cfg.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	maxResponse := cfg.MaxResponseBytes
+	if maxResponse <= 0 {
+		maxResponse = defaultMaxResponseBytes
+	}
+
 	return &Client{
-		apiKey:  cfg.APIKey,
-		baseURL: baseURL,
-		http:    hc,
+		apiKey:       cfg.APIKey,
+		baseURL:      baseURL,
+		http:         hc,
+		maxRetries:   max(cfg.MaxRetries, 0),
+		retryBackoff: backoff,
+		maxResponse:  maxResponse,
 	}, nil
 }
 
@@ -71,21 +107,23 @@ type ContentsRequest struct {
 }
 
 type ContentsResponse struct {
-	RequestID string            `json:"requestId"`
+	RequestID string              `json:"requestId"`
 	Results   []ResultWithContent `json:"results"`
-	Context   string            `json:"context"`
-	Statuses  []ContentStatus    `json:"statuses"`
+	Context   string              `json:"context"`
+	Statuses  []ContentStatus     `json:"statuses"`
 }
 
 type ResultWithContent struct {
-	ID            string  `json:"id"`
-	URL           string  `json:"url"`
-	Title         string   `json:"title"`
-	Author        *string  `json:"author"`
-	PublishedDate *string  `json:"publishedDate"`
-	Text          string   `json:"text"`
-	Highlights    []string `json:"highlights"`
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	Title         string          `json:"title"`
+	Author        *string         `json:"author"`
+	PublishedDate *string         `json:"publishedDate"`
+	Text          string          `json:"text"`
+	Highlights    []string        `json:"highlights"`
 	Summary       json.RawMessage `json:"summary"`
+	// Image is the page's preview image, when Exa found one.
+	Image string `json:"image"`
 }
 
 type ContentStatus struct {
@@ -95,7 +133,7 @@ type ContentStatus struct {
 }
 
 type StatusError struct {
-	Tag           string `json:"tag,omitempty"`
+	Tag            string `json:"tag,omitempty"`
 	HTTPStatusCode *int   `json:"httpStatusCode,omitempty"`
 }
 
@@ -118,33 +156,97 @@ func (c *Client) Contents(ctx context.Context, req ContentsRequest) (*ContentsRe
 		return nil, fmt.Errorf("exa contents: marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/contents", bytes.NewReader(b))
+	raw, err := c.post(ctx, "/contents", b, "exa contents")
 	if err != nil {
-		return nil, fmt.Errorf("exa contents: create request: %w", err)
+		return nil, err
+	}
+
+	var parsed ContentsResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("exa contents: unmarshal response: %w", err)
+	}
+	return &parsed, nil
+}
+
+// post sends a JSON body to the given API path and returns the raw response
+// body. Network errors and 5xx responses are retried with exponential backoff
+// up to maxRetries times, or the retry.Limit on ctx, as long as the
+// retry.Budget on ctx has retries left; op prefixes returned errors.
+func (c *Client) post(ctx context.Context, path string, body []byte, op string) ([]byte, error) {
+	maxRetries := retry.Limit(ctx, c.maxRetries)
+	budget := retry.FromContext(ctx)
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if !budget.Take() {
+				return nil, fmt.Errorf("%s: retry budget exhausted: %w", op, lastErr)
+			}
+			delay := c.retryBackoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%s: %w (last error: %v)", op, ctx.Err(), lastErr)
+			case <-time.After(delay):
+			}
+		}
+
+		raw, retryable, err := c.postOnce(ctx, path, body, op)
+		if err == nil {
+			return raw, nil
+		}
+		if !retryable {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *Client) postOnce(ctx context.Context, path string, body []byte, op string) ([]byte, bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: create request: %w", op, err)
 	}
 	httpReq.Header.Set("x-api-key", c.apiKey)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.http.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("exa contents: request: %w", err)
+		return nil, isTransient(ctx, err), fmt.Errorf("%s: request: %w", op, err)
 	}
 	defer resp.Body.Close()
 
-	raw, err := io.ReadAll(resp.Body)
+	// read one byte past the cap to tell a body that fits from one that doesn't
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponse+1))
 	if err != nil {
-		return nil, fmt.Errorf("exa contents: read response: %w", err)
+		return nil, isTransient(ctx, err), fmt.Errorf("%s: read response: %w", op, err)
+	}
+	if int64(len(raw)) > c.maxResponse {
+		return nil, false, fmt.Errorf("%s: %w: over %d bytes", op, ErrResponseTooLarge, c.maxResponse)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(raw)}
+		return nil, resp.StatusCode >= 500, &APIError{StatusCode: resp.StatusCode, Body: string(raw)}
 	}
+	return raw, false, nil
+}
 
-	var parsed ContentsResponse
-	if err := json.Unmarshal(raw, &parsed); err != nil {
-		return nil, fmt.Errorf("exa contents: unmarshal response: %w", err)
+// isTransient reports whether a transport error is worth retrying. Errors
+// caused by the caller's context ending are not.
+func isTransient(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
 	}
-	return &parsed, nil
+	// *url.Error satisfies net.Error itself, so look at what it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
 }
 
 type HighlightsOptions struct {
@@ -165,7 +267,7 @@ type AnswerRequest struct {
 }
 
 type AnswerResponse struct {
-	Answer    string          `json:"answer"`
+	Answer    string           `json:"answer"`
 	Citations []AnswerCitation `json:"citations"`
 }
 
@@ -187,25 +289,9 @@ func (c *Client) Answer(ctx context.Context, req AnswerRequest) (*AnswerResponse
 		return nil, fmt.Errorf("exa answer: marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/answer", bytes.NewReader(b))
+	raw, err := c.post(ctx, "/answer", b, "exa answer")
 	if err != nil {
-		return nil, fmt.Errorf("exa answer: create request: %w", err)
-	}
-	httpReq.Header.Set("x-api-key", c.apiKey)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("exa answer: request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	raw, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("exa answer: read response: %w", err)
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(raw)}
+		return nil, err
 	}
 
 	var parsed AnswerResponse
@@ -214,4 +300,3 @@ func (c *Client) Answer(ctx context.Context, req AnswerRequest) (*AnswerResponse
 	}
 	return &parsed, nil
 }
-
@@ -0,0 +1,98 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter spaces out requests to the same host by a minimum interval,
+// so bulk operations don't hammer one site. Requests to different hosts
+// don't wait on each other. A nil HostLimiter doesn't limit.
+type HostLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// NewHostLimiter returns a limiter allowing one request per interval to each
+// host, or nil when interval is not positive.
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	if interval <= 0 {
+		return nil
+	}
+	return &HostLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a request to host may be sent, or ctx ends.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil {
+		return nil
+	}
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+
+	// reserve the host's next slot up front so concurrent callers queue up
+	// behind each other instead of all waking at once
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.prune(now)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prune drops hosts whose slots have passed, keeping the map to the hosts
+// being fetched right now. l.mu must be held.
+func (l *HostLimiter) prune(now time.Time) {
+	for host, next := range l.next {
+		if next.Before(now) {
+			delete(l.next, host)
+		}
+	}
+}
+
+// Transport wraps next so every request, redirect hops included, waits for
+// its host's turn. It returns next unchanged for a nil limiter.
+func (l *HostLimiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if l == nil {
+		return next
+	}
+	return &limitedTransport{next: next, limiter: l}
+}
+
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *HostLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
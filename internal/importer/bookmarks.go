@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParseBookmarks reads the Netscape bookmarks.html format every browser
+// exports. Each link is tagged with the folders it sits in, leaving out the
+// browser's own toolbar and "other bookmarks" roots.
+func ParseBookmarks(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	// folders holds the folder each open <DL> belongs to, "" for roots
+	var folders []string
+	// folder is the name of the last <H3>, which titles the next <DL>
+	folder := ""
+	inFolderName := false
+	var current *Entry
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return entries, nil
+			}
+			return nil, z.Err()
+
+		case html.StartTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "h3":
+				// root folders keep an empty name and tag nothing
+				folder = ""
+				inFolderName = !isRootFolder(tok.Attr)
+			case "dl":
+				folders = append(folders, strings.TrimSpace(folder))
+				folder = ""
+			case "a":
+				entry := Entry{Tags: folderTags(folders)}
+				for _, attr := range tok.Attr {
+					switch attr.Key {
+					case "href":
+						entry.Link = strings.TrimSpace(attr.Val)
+					case "add_date":
+						entry.AddedAt = unixTime(attr.Val)
+					case "tags":
+						entry.Tags = append(entry.Tags, splitTags(attr.Val)...)
+					}
+				}
+				if entry.Link != "" {
+					entries = append(entries, entry)
+					current = &entries[len(entries)-1]
+				}
+			}
+
+		case html.TextToken:
+			text := string(z.Text())
+			if current != nil {
+				current.Title += strings.TrimSpace(text)
+			} else if inFolderName {
+				folder += text
+			}
+
+		case html.EndTagToken:
+			switch z.Token().Data {
+			case "h3":
+				inFolderName = false
+			case "a":
+				current = nil
+			case "dl":
+				if len(folders) > 0 {
+					folders = folders[:len(folders)-1]
+				}
+			}
+		}
+	}
+}
+
+// isRootFolder reports whether an <H3> is one of the browser's built-in
+// folders, which say where a bookmark lives rather than what it is about.
+func isRootFolder(attrs []html.Attribute) bool {
+	for _, attr := range attrs {
+		switch attr.Key {
+		case "personal_toolbar_folder", "unfiled_bookmarks_folder":
+			return true
+		}
+	}
+	return false
+}
+
+func folderTags(folders []string) []string {
+	var tags []string
+	for _, name := range folders {
+		if name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,39 @@
+// Package importer parses reading lists exported by other services into
+// entries ready to be saved as articles.
+package importer
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one saved link from an export.
+type Entry struct {
+	Link  string
+	Title string
+	// AddedAt is when the link was saved, zero when the export doesn't say.
+	AddedAt time.Time
+	// Read reports whether the source had it marked as read or archived.
+	Read bool
+	Tags []string
+}
+
+// unixTime parses the seconds-since-epoch timestamps used by HTML exports.
+func unixTime(raw string) time.Time {
+	secs, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || secs <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+func splitTags(raw string) []string {
+	var tags []string
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ParsePocket reads Pocket's ril_export.html: an "Unread" and a
+// "Read Archive" heading, each followed by a list of
+// <li><a href time_added tags>title</a></li> entries.
+func ParsePocket(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	read := false
+	inHeading := false
+	var current *Entry
+
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return entries, nil
+			}
+			return nil, z.Err()
+
+		case html.StartTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "h1":
+				inHeading = true
+			case "a":
+				entry := Entry{Read: read}
+				for _, attr := range tok.Attr {
+					switch attr.Key {
+					case "href":
+						entry.Link = strings.TrimSpace(attr.Val)
+					case "time_added":
+						entry.AddedAt = unixTime(attr.Val)
+					case "tags":
+						entry.Tags = splitTags(attr.Val)
+					}
+				}
+				if entry.Link != "" {
+					entries = append(entries, entry)
+					current = &entries[len(entries)-1]
+				}
+			}
+
+		case html.TextToken:
+			text := strings.TrimSpace(string(z.Text()))
+			if inHeading {
+				read = strings.Contains(strings.ToLower(text), "read archive")
+			} else if current != nil {
+				current.Title += text
+			}
+
+		case html.EndTagToken:
+			switch z.Token().Data {
+			case "h1":
+				inHeading = false
+			case "a":
+				current = nil
+			}
+		}
+	}
+}
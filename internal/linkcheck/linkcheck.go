@@ -0,0 +1,140 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+const defaultConcurrency = 5
+const userAgent = "reading-list-api link checker"
+
+const (
+	StatusOK          = "ok"
+	StatusDead        = "dead"
+	StatusUnreachable = "unreachable"
+)
+
+// Target is a stored link to check.
+type Target struct {
+	ID   int
+	Link string
+}
+
+type Result struct {
+	ID         int    `json:"id"`
+	Link       string `json:"link"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Checker struct {
+	http        *http.Client
+	concurrency int
+}
+
+type CheckerConfig struct {
+	// Optional. Maximum number of links checked at once.
+	Concurrency int
+
+	// Optional. If set, used only when HTTPClient is nil.
+	Timeout time.Duration
+
+	HTTPClient *http.Client
+}
+
+func NewChecker(cfg CheckerConfig) *Checker {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	hc := cfg.HTTPClient
+	if hc == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		hc = &http.Client{Timeout: timeout}
+	}
+
+	return &Checker{
+		http:        hc,
+		concurrency: concurrency,
+	}
+}
+
+// CheckAll checks every target, at most c.concurrency at a time, and returns
+// the results in the same order as targets. Targets not reached before ctx
+// ends are reported as unreachable.
+func (c *Checker) CheckAll(ctx context.Context, targets []Target) []Result {
+	results := make([]Result, len(targets))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+
+	for i, t := range targets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i] = Result{ID: t.ID, Link: t.Link, Status: StatusUnreachable, Error: ctx.Err().Error()}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.Check(ctx, t)
+		}(i, t)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// Check requests a single link, trying HEAD first and falling back to GET
+// for servers that don't support HEAD.
+func (c *Checker) Check(ctx context.Context, t Target) Result {
+	res := Result{ID: t.ID, Link: t.Link}
+
+	code, err := c.request(ctx, http.MethodHead, t.Link)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		code, err = c.request(ctx, http.MethodGet, t.Link)
+	}
+	if err != nil {
+		res.Status = StatusUnreachable
+		res.Error = err.Error()
+		return res
+	}
+
+	res.StatusCode = code
+	switch {
+	case code == http.StatusNotFound || code == http.StatusGone:
+		res.Status = StatusDead
+	case code >= 500:
+		res.Status = StatusUnreachable
+	default:
+		res.Status = StatusOK
+	}
+	return res
+}
+
+func (c *Checker) request(ctx context.Context, method string, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,204 @@
+package pagemeta
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	htmltomarkdown "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page holds the metadata found in a page's <head> plus its body converted
+// to markdown.
+type Page struct {
+	Title         string
+	Author        string
+	Description   string
+	DatePublished string
+	// Type uses the same codes as types.Article: 0=article, 1=paper, 2=book.
+	Type     int
+	Markdown string
+	// SiteName is the publication's display name, e.g. "The New York Times".
+	SiteName string
+	// Image is the absolute URL of the page's preview image, from og:image or
+	// twitter:image, else its first large <img>.
+	Image string
+
+	// Identifiers from citation meta tags, when the page is a paper.
+	DOI     string
+	ArxivID string
+}
+
+var datePrefix = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?`)
+
+// Parse reads the OpenGraph, Twitter, citation and standard meta tags of
+// rawHTML. link is used to resolve relative links in the markdown.
+func Parse(link string, rawHTML string) (*Page, error) {
+	doc, err := html.Parse(strings.NewReader(rawHTML))
+	if err != nil {
+		return nil, fmt.Errorf("pagemeta: parse html: %w", err)
+	}
+
+	meta := map[string][]string{}
+	var docTitle string
+	var bodyImage string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Meta:
+				key := strings.ToLower(attr(n, "property"))
+				if key == "" {
+					key = strings.ToLower(attr(n, "name"))
+				}
+				if content := strings.TrimSpace(attr(n, "content")); key != "" && content != "" {
+					meta[key] = append(meta[key], content)
+				}
+			case atom.Title:
+				if docTitle == "" && n.FirstChild != nil {
+					docTitle = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case atom.Img:
+				if bodyImage == "" && largeImage(n) {
+					bodyImage = strings.TrimSpace(attr(n, "src"))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	page := &Page{
+		Title:       first(meta, "og:title", "twitter:title", "citation_title"),
+		Description: first(meta, "og:description", "description", "twitter:description"),
+	}
+	if page.Title == "" {
+		page.Title = docTitle
+	}
+
+	if authors := meta["citation_author"]; len(authors) > 0 {
+		page.Author = strings.Join(authors, ", ")
+	} else {
+		page.Author = first(meta, "author", "article:author", "book:author", "twitter:creator")
+	}
+
+	published := first(meta, "article:published_time", "citation_publication_date", "citation_date", "book:release_date", "date")
+	page.DatePublished = datePrefix.FindString(strings.ReplaceAll(published, "/", "-"))
+
+	page.SiteName = first(meta, "og:site_name", "application-name", "citation_journal_title")
+
+	page.Image = ResolveImage(link, first(meta, "og:image", "og:image:url", "og:image:secure_url", "twitter:image", "twitter:image:src"))
+	if page.Image == "" {
+		page.Image = ResolveImage(link, bodyImage)
+	}
+
+	page.DOI = first(meta, "citation_doi", "dc.identifier.doi", "prism.doi")
+	page.ArxivID = first(meta, "citation_arxiv_id")
+
+	switch {
+	case first(meta, "og:type") == "book" || first(meta, "book:isbn") != "":
+		page.Type = 2
+	case first(meta, "citation_title", "citation_doi", "citation_journal_title", "citation_arxiv_id") != "":
+		page.Type = 1
+	}
+
+	md, err := htmltomarkdown.ConvertNode(doc, converter.WithDomain(link))
+	if err != nil {
+		return nil, fmt.Errorf("pagemeta: convert to markdown: %w", err)
+	}
+	page.Markdown = string(md)
+
+	return page, nil
+}
+
+// LeadSentence returns the first sentence of the first prose paragraph of
+// markdown, capped at maxWords. It is a cheap stand-in for a summary when a
+// page has no description.
+func LeadSentence(markdown string, maxWords int) string {
+	for _, para := range strings.Split(markdown, "\n\n") {
+		para = strings.TrimSpace(para)
+		// skip headings, lists, quotes, images, code and tables
+		if para == "" || strings.ContainsAny(para[:1], "#-*>!|`[0123456789") {
+			continue
+		}
+		words := strings.Fields(para)
+		if len(words) < 6 {
+			continue
+		}
+
+		var out []string
+		for _, w := range words {
+			out = append(out, w)
+			if strings.HasSuffix(w, ".") || strings.HasSuffix(w, "?") || strings.HasSuffix(w, "!") {
+				break
+			}
+			if len(out) == maxWords {
+				return strings.Join(out, " ") + "…"
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	return ""
+}
+
+// minImageSide is the smallest width and height an <img> must declare to
+// stand in for a missing og:image; smaller ones are icons and avatars.
+const minImageSide = 200
+
+func largeImage(n *html.Node) bool {
+	width, err := strconv.Atoi(strings.TrimSuffix(attr(n, "width"), "px"))
+	if err != nil {
+		return false
+	}
+	height, err := strconv.Atoi(strings.TrimSuffix(attr(n, "height"), "px"))
+	if err != nil {
+		return false
+	}
+	return width >= minImageSide && height >= minImageSide
+}
+
+// ResolveImage makes src absolute against the page link, dropping anything
+// that isn't an http(s) URL, such as inline data: images.
+func ResolveImage(link string, src string) string {
+	if src == "" {
+		return ""
+	}
+	base, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(src)
+	if err != nil {
+		return ""
+	}
+	u := base.ResolveReference(ref)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
+
+func first(meta map[string][]string, keys ...string) string {
+	for _, k := range keys {
+		if v := meta[k]; len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
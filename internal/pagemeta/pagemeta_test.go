@@ -0,0 +1,57 @@
+package pagemeta
+
+import "testing"
+
+func TestParseImage(t *testing.T) {
+	const link = "https://example.com/posts/a-post"
+	tests := []struct {
+		name string
+		head string
+		body string
+		want string
+	}{
+		{
+			name: "og:image",
+			head: `<meta property="og:image" content="https://cdn.example.com/og.png">` +
+				`<meta name="twitter:image" content="https://cdn.example.com/twitter.png">`,
+			want: "https://cdn.example.com/og.png",
+		},
+		{
+			name: "twitter:image fallback",
+			head: `<meta name="twitter:image" content="https://cdn.example.com/twitter.png">`,
+			want: "https://cdn.example.com/twitter.png",
+		},
+		{
+			name: "relative url",
+			head: `<meta property="og:image" content="/images/cover.jpg">`,
+			want: "https://example.com/images/cover.jpg",
+		},
+		{
+			name: "data uri",
+			head: `<meta property="og:image" content="data:image/png;base64,iVBORw0KGgo=">`,
+			want: "",
+		},
+		{
+			name: "large body image",
+			body: `<img src="icon.png" width="32" height="32"><img src="figure.png" width="800" height="600">`,
+			want: "https://example.com/posts/figure.png",
+		},
+		{
+			name: "no image",
+			body: `<img src="icon.png" width="32" height="32"><img src="unsized.png">`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawHTML := "<html><head><title>A post</title>" + tt.head + "</head><body>" + tt.body + "</body></html>"
+			page, err := Parse(link, rawHTML)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if page.Image != tt.want {
+				t.Errorf("Image = %q, want %q", page.Image, tt.want)
+			}
+		})
+	}
+}
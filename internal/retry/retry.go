@@ -0,0 +1,79 @@
+package retry
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey int
+
+const (
+	budgetKey contextKey = iota
+	limitKey
+)
+
+// Budget is the number of retries every upstream call made for one
+// operation may spend between them, so per-call retries can't multiply into
+// a pathological number of attempts. A nil Budget is unlimited.
+type Budget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+// NewBudget returns a budget of total retries, or nil, meaning unlimited,
+// when total is negative.
+func NewBudget(total int) *Budget {
+	if total < 0 {
+		return nil
+	}
+	return &Budget{remaining: total}
+}
+
+// Take spends one retry, reporting false when none are left.
+func (b *Budget) Take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// Remaining is the number of retries left, -1 for an unlimited budget.
+func (b *Budget) Remaining() int {
+	if b == nil {
+		return -1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+// WithBudget returns a context whose calls draw their retries from b.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey, b)
+}
+
+// FromContext returns the budget carried by ctx, nil when there is none.
+func FromContext(ctx context.Context) *Budget {
+	b, _ := ctx.Value(budgetKey).(*Budget)
+	return b
+}
+
+// WithLimit returns a context whose calls retry at most n times each, the
+// cap of one stage of the operation.
+func WithLimit(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, limitKey, max(n, 0))
+}
+
+// Limit returns the per-call retry cap carried by ctx, or def without one.
+func Limit(ctx context.Context, def int) int {
+	if n, ok := ctx.Value(limitKey).(int); ok {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// RequireAdmin guards admin routes with the ADMIN_TOKEN bearer token. With
+// no token configured the admin routes are disabled outright rather than
+// left open.
+func (s *Server) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			render.Render(w, r, ErrForbidden(errors.New("admin endpoints are disabled, set ADMIN_TOKEN to enable them")))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			render.Render(w, r, ErrUnauthorized(errors.New("missing or invalid admin token")))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
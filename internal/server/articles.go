@@ -5,16 +5,28 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"reading-list-api/internal/database"
 	"reading-list-api/internal/exa"
+	"reading-list-api/internal/pagemeta"
+	"reading-list-api/internal/retry"
+	"reading-list-api/internal/tracing"
 	"reading-list-api/internal/types"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type contextKey string
@@ -24,13 +36,25 @@ const (
 	PageSizeCtxKey contextKey = "PageSize"
 )
 
+// TotalCountHeader carries the total number of articles on list responses.
+const TotalCountHeader = "X-Total-Count"
+
+// SnapshotHeader carries the snapshot token of a page listing; passing it
+// back as ?snapshot keeps later pages from shifting as articles are added.
+const SnapshotHeader = "X-Snapshot-Token"
+
 type ArticleResponse struct {
 	*types.Article
+	// Tags are the article's tags, an empty list when it has none. Page
+	// listings render plain articles and leave them out.
+	Tags []string `json:"tags"`
 }
 
 type ArticlePageResponse struct {
 	TotalArticles int             `json:"totalArticles"`
 	Articles      []types.Article `json:"articles"`
+	Snapshot      string          `json:"snapshot,omitempty"`
+	NextCursor    string          `json:"nextCursor,omitempty"`
 }
 
 func Paginate(next http.Handler) http.Handler {
@@ -61,40 +85,161 @@ func (s *Server) GetArticlesPageHandler(w http.ResponseWriter, r *http.Request)
 	page := r.Context().Value(PageCtxKey).(int)
 	pageSize := r.Context().Value(PageSizeCtxKey).(int)
 
-	// 0.5 get total number of articles in db
-	total, err := s.db.GetArticleCount()
+	filter, err := articleFilter(r, s.defaultOrder)
 	if err != nil {
-		render.Render(w, r, ErrInternalServer(fmt.Errorf("error getting total article count: %v", err)))
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	if r.URL.Query().Has("cursor") {
+		s.cursorPage(w, r, filter, pageSize)
 		return
 	}
 
+	// hot pages are served from the cache until the next write
+	key := pageCacheKey(page, pageSize, filter)
+	version := s.store.DataVersion()
+	result, ok := s.pageCache.get(key, version)
+	if !ok {
+		result, err = s.articlePage(filter, page, pageSize)
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		s.pageCache.put(key, version, result)
+	}
+
+	if result.snapshot != "" {
+		w.Header().Set(SnapshotHeader, result.snapshot)
+	}
+	w.Header().Set(TotalCountHeader, strconv.Itoa(result.total))
+
+	// 2 - return list of articles as a response
+	resp := NewArticlePageResponse(result.articles, result.total)
+	resp.Snapshot = result.snapshot
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// articlePage reads one page of the listing from the database.
+func (s *Server) articlePage(filter database.ArticleFilter, page int, pageSize int) (cachedPage, error) {
+	var err error
+	// without a snapshot token this is a first page; pin the listing to
+	// what exists now so the client's later pages line up with it
+	if filter.MaxID == 0 {
+		filter.MaxID, err = s.store.GetMaxArticleID()
+		if err != nil {
+			return cachedPage{}, err
+		}
+	}
+	result := cachedPage{}
+	if filter.MaxID > 0 {
+		result.snapshot = strconv.Itoa(filter.MaxID)
+	}
+
+	// 0.5 get total number of articles in db
+	result.total, err = s.store.GetArticleCount(filter)
+	if err != nil {
+		return cachedPage{}, fmt.Errorf("error getting total article count: %v", err)
+	}
+
 	offset := (page - 1) * pageSize
 
-	if offset < 0 || offset >= total || total == 0 {
+	if offset < 0 || offset >= result.total || result.total == 0 {
 		empty := make([]types.Article, 0)
-		err = render.Render(w, r, NewArticlePageResponse(&empty, total))
+		result.articles = &empty
+		return result, nil
+	}
+
+	// 1 - query sqlite db for all articles
+	result.articles, err = s.store.GetArticlePage(filter, offset, pageSize)
+	if err != nil {
+		return cachedPage{}, err
+	}
+	return result, nil
+}
+
+// cursorPage serves GET /articles in cursor mode: ?cursor with no value
+// starts the listing and each page's nextCursor continues it right after its
+// last article, however the library changed in between. The last page has no
+// nextCursor.
+func (s *Server) cursorPage(w http.ResponseWriter, r *http.Request, filter database.ArticleFilter, pageSize int) {
+	var after *database.ArticleCursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		cursor, err := database.ParseArticleCursor(token)
 		if err != nil {
-			render.Render(w, r, ErrRender(err))
+			render.Render(w, r, ErrInvalidRequest(err))
+			return
 		}
+		if cursor.Order != filter.Order {
+			render.Render(w, r, ErrInvalidRequest(errors.New("cursor belongs to a listing in another order, start again without one")))
+			return
+		}
+		after = &cursor
+	}
+
+	total, err := s.store.GetArticleCount(filter)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(fmt.Errorf("error getting total article count: %v", err)))
 		return
 	}
 
-	// 1 - query sqlite db for all articles
-	pageArticles, err := s.db.GetArticlePage(offset, pageSize)
+	// one extra row tells whether another page follows
+	articles, err := s.store.GetArticlePageAfterCursor(filter, after, pageSize+1)
 	if err != nil {
 		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
-	// fmt.Println("pages", len(*pageArticles), *pageArticles)
+	more := len(*articles) > pageSize
+	if more {
+		*articles = (*articles)[:pageSize]
+	}
 
-	// 2 - return list of articles as a response
-	err = render.Render(w, r, NewArticlePageResponse(pageArticles, total))
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	resp := NewArticlePageResponse(articles, total)
+	if more {
+		resp.NextCursor = database.CursorAfter(&(*articles)[pageSize-1], filter.Order).Encode()
+	}
+	err = render.Render(w, r, resp)
 	if err != nil {
 		render.Render(w, r, ErrRender(err))
 		return
 	}
 }
 
+// HeadArticlesPageHandler mirrors the headers of GetArticlesPageHandler without
+// querying or serializing the page itself.
+func (s *Server) HeadArticlesPageHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := articleFilter(r, s.defaultOrder)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	// pinned to a snapshot the way articlePage does, outside cursor mode
+	if !r.URL.Query().Has("cursor") && filter.MaxID == 0 {
+		filter.MaxID, err = s.store.GetMaxArticleID()
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+	}
+	total, err := s.store.GetArticleCount(filter)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(fmt.Errorf("error getting total article count: %v", err)))
+		return
+	}
+	if !r.URL.Query().Has("cursor") && filter.MaxID > 0 {
+		w.Header().Set(SnapshotHeader, strconv.Itoa(filter.MaxID))
+	}
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
+
 func NewArticlePageResponse(articles *[]types.Article, totalArticles int) *ArticlePageResponse {
 	var articlePageList []types.Article
 	if len(*articles) == 0 {
@@ -113,32 +258,85 @@ func (rd *ArticlePageResponse) Render(w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
+// GetAllArticlesHandler streams every article as one JSON array, encoding
+// each row as it is read so memory stays flat however large the library is.
+// An error after the first article can only cut the array short, which
+// leaves the body invalid JSON rather than a silently partial list.
 func (s *Server) GetAllArticlesHandler(w http.ResponseWriter, r *http.Request) {
-	// 1 - query sqlite db for all articles
-	articles, err := s.db.GetAllArticles()
+	// a large library can take longer to send than the server's write timeout
+	const allArticlesWriteTimeout = 5 * time.Minute
+
+	order, err := articleOrder(r, s.defaultOrder)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(allArticlesWriteTimeout))
+
+	tags, err := s.store.GetTagsByArticle()
 	if err != nil {
 		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
-	// 2 - return list of articles as a response
-	err = render.RenderList(w, r, NewArticleListResponse(articles))
+
+	enc := json.NewEncoder(w)
+	sparse := s.wantsSparse(r)
+	started := false
+	err = s.store.EachArticle(r.Context(), order, func(article *types.Article) error {
+		sep := ","
+		if !started {
+			w.Header().Set("Content-Type", "application/json")
+			sep = "["
+			started = true
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		if !sparse {
+			return enc.Encode(NewArticleResponse(article, tags[article.ID]))
+		}
+		data, err := json.Marshal(NewArticleResponse(article, tags[article.ID]))
+		if err == nil {
+			data, err = omitEmpty(data)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
 	if err != nil {
-		render.Render(w, r, ErrRender(err))
+		if !started {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		log.Printf("error streaming articles: %v", err)
 		return
 	}
+
+	if !started {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "[")
+	}
+	io.WriteString(w, "]\n")
 }
 
-func NewArticleListResponse(articles *[]types.Article) []render.Renderer {
+// NewArticleListResponse renders articles with their tags, looked up in
+// tags by article id.
+func NewArticleListResponse(articles *[]types.Article, tags map[int][]string) []render.Renderer {
 	list := []render.Renderer{}
 
 	for _, article := range *articles {
-		list = append(list, NewArticleResponse(&article))
+		list = append(list, NewArticleResponse(&article, tags[article.ID]))
 	}
 	return list
 }
 
-func NewArticleResponse(article *types.Article) *ArticleResponse {
-	resp := &ArticleResponse{Article: article}
+func NewArticleResponse(article *types.Article, tags []string) *ArticleResponse {
+	if tags == nil {
+		tags = []string{}
+	}
+	resp := &ArticleResponse{Article: article, Tags: tags}
 	return resp
 }
 
@@ -152,105 +350,579 @@ func (s *Server) CreateArticle(w http.ResponseWriter, r *http.Request) {
 	err := render.Bind(r, data)
 	if err != nil {
 		fmt.Println("error decoding request data", err)
-		render.Render(w, r, ErrInvalidRequest((err)))
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	if skipExtraction(r) {
+		s.createArticle(w, r, ExtractorClient, data.ArticleLink, func(ctx context.Context) (*types.Article, error) {
+			return s.articleFromRequest(data), nil
+		})
+		return
+	}
+
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	// extraction takes long enough to time out mobile clients, so it runs
+	// in the background and the client polls GET /articles/{id}
+	s.enqueueArticle(w, r, data.ArticleLink)
+}
+
+func (s *Server) GetArticleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
 		return
 	}
-	// 2 - check if the link already exists in the db
-	articleLink := data.ArticleLink
-	exists, err := s.db.ArticleExists(articleLink)
 	if err != nil {
 		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
-	if exists {
-		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("article exists in db")))
+
+	// with ?wait=true a pending article is held until its extraction ends
+	if r.URL.Query().Get("wait") == "true" {
+		article, err = s.waitForExtraction(r.Context(), article)
+		if r.Context().Err() != nil {
+			return
+		}
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+	}
+
+	setExtractorHeader(w, article)
+
+	resp, err := s.articleWithTags(article)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
 
-	// 3 - extract article metadata using Exa
-	article, err := extractArticleMetadata(articleLink)
+	// the client can revalidate a cached copy with If-None-Match
+	unchanged, err := s.notModified(w, r, resp)
 	if err != nil {
 		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
+	if unchanged {
+		return
+	}
 
-	if article.Type == -1 {
-		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("link supplied is not an article or book")))
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
 		return
 	}
+}
 
-	// 4 - create a db record for this article and populate all the fields
-	err = s.db.InsertArticle(article)
+// HeadArticleByIDHandler sends the headers of GetArticleByIDHandler, ETag
+// included, without the body, so a client can check whether its copy is
+// current. It never waits for an extraction.
+func (s *Server) HeadArticleByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
 	if err != nil {
-		fmt.Println("error inserting article to db", err)
 		render.Render(w, r, ErrInternalServer(err))
 		return
 	}
 
+	setExtractorHeader(w, article)
+
+	resp, err := s.articleWithTags(article)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	unchanged, err := s.notModified(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	if unchanged {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteArticle removes an article saved by mistake, with its tags,
+// alternate links and history. It answers 204 with no body.
+func (s *Server) DeleteArticle(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	err = s.store.DeleteArticle(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// GetArticleRawHandler returns the article text kept at extraction time as
+// markdown. Articles saved without STORE_CONTENT have none and are a 404.
+func (s *Server) GetArticleRawHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	if article.Content == "" {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	io.WriteString(w, article.Content)
+}
+
+// extractFunc produces the metadata for a new article.
+type extractFunc func(ctx context.Context) (*types.Article, error)
+
+// createArticle runs saveArticle for a request and renders the outcome.
+func (s *Server) createArticle(w http.ResponseWriter, r *http.Request, mode string, originalLink string, extract extractFunc) {
+	// with ?upsert=true an existing link is re-extracted and refreshed
+	upsert := r.URL.Query().Get("upsert") == "true"
+
+	// the save keeps running if the client goes away, but never past the
+	// deadline, which also cancels any extraction still in flight
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), s.createArticleTimeout)
+	defer cancel()
+
+	article, errResp := s.saveArticle(ctx, mode, originalLink, upsert, extract)
+	if errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	setExtractorHeader(w, article)
+
 	// 5 - return posted article
-	err = render.Render(w, r, NewArticleResponse(article))
+	s.renderArticle(w, r, article)
+}
+
+// saveArticle runs the steps shared by every way of adding an article: the
+// duplicate check, extraction and insert. Extraction sees the link as
+// submitted; the normalized form is what gets stored and deduped. Failures
+// come back as the error response to render, so callers outside a request
+// can log them instead.
+//
+// Concurrent saves of the same link in the same mode share one run, so a
+// link submitted twice at once is extracted and inserted once. mode names
+// where the metadata comes from, ExtractorClient or ExtractorHTML.
+func (s *Server) saveArticle(ctx context.Context, mode string, originalLink string, upsert bool, extract extractFunc) (*types.Article, render.Renderer) {
+	articleLink, err := normalizeURL(originalLink)
 	if err != nil {
-		render.Render(w, r, ErrRender(err))
+		return nil, ErrInvalidRequest(err)
+	}
+
+	key := fmt.Sprintf("%s|%t|%s", mode, upsert, articleLink)
+	return s.saving.do(ctx, key, func() (*types.Article, render.Renderer) {
+		return s.saveNormalized(ctx, mode, articleLink, originalLink, upsert, extract)
+	})
+}
+
+func (s *Server) saveNormalized(ctx context.Context, mode string, articleLink string, originalLink string, upsert bool, extract extractFunc) (*types.Article, render.Renderer) {
+	// 2 - check if the link already exists in the db, as a primary or an
+	// alternate link
+	existing, err := s.store.GetArticleByLink(articleLink)
+	if err != nil && !errors.Is(err, database.ErrArticleNotFound) {
+		return nil, ErrInternalServer(err)
+	}
+	exists := existing != nil
+	if exists && !upsert {
+		return nil, ErrConflict(database.ErrArticleExists)
+	}
+	if exists {
+		// an upsert through a mirror updates the article it belongs to
+		articleLink = existing.Link
+	}
+
+	// papers are deduped by DOI/arXiv id, which many URLs can share
+	if conflict, err := s.paperConflict(paperIDFromLink(articleLink), articleLink); err != nil {
+		return nil, ErrInternalServer(err)
+	} else if conflict != nil {
+		return nil, conflict
+	}
+
+	// refuse new articles once the library is full, before spending an
+	// extraction on them
+	if !exists {
+		if errResp := s.checkLibraryFull(); errResp != nil {
+			return nil, errResp
+		}
+	}
+
+	// 3 - extract article metadata
+	if errResp := s.acquireSyncExtraction(ctx); errResp != nil {
+		return nil, errResp
+	}
+	article, err := s.runSyncExtraction(ctx, extract)
+	// metadata the client sent wasn't extracted, so it doesn't count
+	// towards the extraction success rate
+	if mode != ExtractorClient {
+		s.extractionStats.record(extractionOutcome(ctx, article, err))
+	}
+	if ctx.Err() != nil {
+		return nil, errSaveTimedOut(ctx)
+	}
+	if err != nil {
+		return nil, ErrInternalServer(err)
+	}
+
+	if article.Type == types.TypeNotArticle {
+		return nil, ErrInvalidRequest(fmt.Errorf("link supplied is not an article or book"))
+	}
+	article.Link = articleLink
+	article.OriginalLink = originalLink
+	if article.PaperID == "" {
+		article.PaperID = paperIDFromLink(articleLink)
+	}
+	if article.SiteName == "" {
+		article.SiteName = siteNameFromLink(articleLink)
+	}
+	if !s.storeContent {
+		article.Content = ""
+	}
+	s.sanitizeArticle(article)
+	if article.Type == types.TypePaper {
+		if conflict, err := s.paperConflict(article.PaperID, articleLink); err != nil {
+			return nil, ErrInternalServer(err)
+		} else if conflict != nil {
+			return nil, conflict
+		}
+	}
+
+	// 4 - create a db record for this article and populate all the fields;
+	// past the deadline the client has been told it failed, so don't
+	if ctx.Err() != nil {
+		return nil, errSaveTimedOut(ctx)
+	}
+	_, span := tracing.Start(ctx, "db.insert_article")
+	if exists {
+		err = s.store.UpsertArticle(article)
+	} else {
+		err = s.store.InsertArticle(article)
+	}
+	tracing.End(span, err)
+	if errors.Is(err, database.ErrArticleExists) {
+		return nil, ErrConflict(err)
+	}
+	if err != nil {
+		fmt.Println("error inserting article to db", err)
+		return nil, ErrInternalServer(err)
+	}
+	s.applySuggestedTags(article)
+	if exists {
+		// respond with the stored row, which kept its original date_read
+		article, err = s.store.GetArticleByID(article.ID)
+		if err != nil {
+			return nil, ErrInternalServer(err)
+		}
+		s.recordHistory(existing, article, ChangeRefresh)
+	}
+
+	// 4.5 - snapshot the page in the background if archiving is enabled
+	if s.archiver != nil && article.ArchiveURL == "" {
+		go s.archiveArticle(article.ID, article.Link)
+	}
+	if s.embedder != nil {
+		go s.embedArticle(article.ID, embeddingText(article))
+	}
+
+	return article, nil
+}
+
+// librarySize counts the articles MAX_ARTICLES applies to. Saves and
+// imports still being extracted count, or a burst could pass the limit.
+func (s *Server) librarySize() (int, error) {
+	return s.store.GetArticleCount(database.ArticleFilter{AnyExtraction: true})
+}
+
+// checkLibraryFull returns a 403 once MAX_ARTICLES is reached.
+func (s *Server) checkLibraryFull() render.Renderer {
+	if s.maxArticles <= 0 {
+		return nil
+	}
+	total, err := s.librarySize()
+	if err != nil {
+		return ErrInternalServer(err)
+	}
+	if total >= s.maxArticles {
+		return ErrForbidden(fmt.Errorf("library full: limit of %d articles reached", s.maxArticles))
+	}
+	return nil
+}
+
+// paperConflict returns a 409 renderer when another stored article already
+// has paperID. An article with the same link doesn't count, so upserts can
+// refresh a paper.
+func (s *Server) paperConflict(paperID string, articleLink string) (render.Renderer, error) {
+	if paperID == "" {
+		return nil, nil
+	}
+	existing, err := s.store.GetArticleByPaperID(paperID)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.Link == articleLink {
+		return nil, nil
+	}
+	return ErrConflict(fmt.Errorf("paper %s already saved as article %d (%s)", paperID, existing.ID, existing.Link)), nil
+}
+
+// PinRequest optionally sets the manual sort order used among pinned articles.
+type PinRequest struct {
+	SortOrder *int `json:"sortOrder"`
+}
+
+func (s *Server) TogglePinHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
 		return
 	}
 
+	// the body is optional; an empty one just toggles the pin
+	data := &PinRequest{}
+	err = render.DecodeJSON(r.Body, data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = s.store.TogglePinned(id, data.SortOrder)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordHistory(before, article, ChangePin)
+
+	s.renderArticle(w, r, article)
+}
+
+func articleIDParam(r *http.Request) (int, error) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid article id: %s", idStr)
+	}
+	return id, nil
+}
+
+// archiveArticle submits link to the archive service and records the snapshot
+// URL on the article. It runs detached from the request, so failures are
+// logged; the error is returned for callers that count them.
+func (s *Server) archiveArticle(id int, link string) error {
+	const archiveTimeout = 3 * time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), archiveTimeout)
+	defer cancel()
+
+	snapshot, err := s.archiver.Save(ctx, link)
+	if err != nil {
+		log.Printf("error archiving article %d: %v", id, err)
+		return err
+	}
+	if err := s.store.SetArchiveURL(id, snapshot); err != nil {
+		log.Printf("error saving archive url for article %d: %v", id, err)
+		return err
+	}
+	return nil
 }
 
 type ArticleRequest struct {
 	ArticleLink string `json:"articleLink"`
+
+	// Metadata the client already has, used as-is with ?skipExtraction=true.
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Summary       string `json:"summary"`
+	DatePublished string `json:"datePublished"`
+	Type          int    `json:"type"`
 }
 
 func (a *ArticleRequest) Bind(r *http.Request) error {
-	if a.ArticleLink == "" {
-		return errors.New("missing required Article fields")
+	invalid := &ValidationError{}
+	if strings.TrimSpace(a.ArticleLink) == "" {
+		invalid.invalidField("articleLink", "required")
+	} else if err := checkLinkInput(a.ArticleLink); err != nil {
+		invalid.invalidField("articleLink", err.Error())
+	} else if !absoluteHTTPURL(a.ArticleLink) {
+		invalid.invalidField("articleLink", "must be an absolute http(s) URL")
 	}
 
-	return nil
+	if skipExtraction(r) {
+		if strings.TrimSpace(a.Title) == "" {
+			invalid.invalidField("title", "required when skipping extraction")
+		}
+		if strings.TrimSpace(a.Summary) == "" {
+			invalid.invalidField("summary", "required when skipping extraction")
+		}
+		if _, ok := types.TypeLabels[a.Type]; !ok {
+			invalid.invalidField("type", "must be 0 (article), 1 (paper) or 2 (book)")
+		}
+	}
+
+	return invalid.orNil()
 }
 
-func extractArticleMetadata(articleLink string) (*types.Article, error) {
-	const (
-		exaTimeout           = 90 * time.Second
-		exaLivecrawlTimeout  = 20000 // ms
-		exaMaxTextCharacters = 12000
-	)
+func skipExtraction(r *http.Request) bool {
+	return r.URL.Query().Get("skipExtraction") == "true"
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), exaTimeout)
-	defer cancel()
+// articleFromRequest builds an article from client-supplied metadata.
+func (s *Server) articleFromRequest(a *ArticleRequest) *types.Article {
+	article := &types.Article{
+		Title:         strings.TrimSpace(a.Title),
+		Author:        strings.TrimSpace(a.Author),
+		Summary:       strings.TrimSpace(a.Summary),
+		DatePublished: strings.TrimSpace(a.DatePublished),
+		Type:          a.Type,
+		DateRead:      time.Now().Format("2006-01-02"),
+		Link:          a.ArticleLink,
+		Extractor:     ExtractorClient,
+	}
+	s.guessAuthor(article, a.ArticleLink)
+	return article
+}
+
+// exaTimeout bounds a whole Exa extraction, retries included.
+const exaTimeout = 90 * time.Second
+
+// exaMaxTextCharacters is how much of a page's text Exa reads, and so what
+// its summary is written from.
+const exaMaxTextCharacters = 12000
+
+// defaultTypeConfidenceThreshold is the confidence below which an extracted
+// type is flagged for the user to confirm.
+const defaultTypeConfidenceThreshold = 0.7
+
+// defaultCreateArticleTimeoutMs bounds a synchronous save, staying under
+// the server's 30s write timeout so the client still gets the 504.
+const defaultCreateArticleTimeoutMs = 25000
+
+// errSaveTimedOut is the response to a save that ran past its deadline.
+func errSaveTimedOut(ctx context.Context) render.Renderer {
+	return ErrGatewayTimeout(fmt.Errorf("saving the article took too long: %v", context.Cause(ctx)))
+}
+
+// defaultFetchRetries caps the retries of each Exa call.
+const defaultFetchRetries = 2
 
-	exaClient, err := exa.NewClient(exa.ClientConfig{
-		APIKey:  os.Getenv("EXA_API_KEY"),
-		Timeout: exaTimeout,
+// defaultExtractRetryBudget is how many retries all the Exa calls of one
+// extraction attempt may spend together.
+const defaultExtractRetryBudget = 4
+
+func newExaClient() (*exa.Client, error) {
+	return exa.NewClient(exa.ClientConfig{
+		APIKey:     os.Getenv("EXA_API_KEY"),
+		Timeout:    exaTimeout,
+		MaxRetries: envInt("FETCH_RETRIES", defaultFetchRetries),
+
+		MaxResponseBytes: int64(envInt("UPSTREAM_MAX_RESPONSE_BYTES", 0)),
 	})
+}
+
+func (s *Server) extractArticleMetadata(ctx context.Context, articleLink string) (*types.Article, error) {
+	const exaLivecrawlTimeout = 20000 // ms
+
+	ctx, cancel := context.WithTimeout(ctx, exaTimeout)
+	defer cancel()
+
+	exaClient, err := newExaClient()
 	if err != nil {
 		return nil, err
 	}
 
 	extracted := (*extractedArticleDetails)(nil)
+	extractor := ExtractorExaContents
 
-	contents, err := exaClient.Contents(ctx, exa.ContentsRequest{
+	contentsCtx, span := tracing.Start(ctx, "exa.contents", attribute.String("article.link", articleLink))
+	contents, err := exaClient.Contents(contentsCtx, exa.ContentsRequest{
 		URLs: []string{articleLink},
 		Text: map[string]any{
 			"maxCharacters":   exaMaxTextCharacters,
 			"includeHtmlTags": false,
 		},
 		Summary: &exa.SummaryOptions{
-			Query:  exaExtractionRulesPrompt(),
-			Schema: exaExtractionSchema(),
+			Query:  exaExtractionRulesPrompt(s.strictAuthor, s.autoTag),
+			Schema: exaExtractionSchema(s.strictAuthor, s.autoTag),
 		},
-		Livecrawl:        "preferred",
+		Livecrawl:        s.exaLivecrawl(),
 		LivecrawlTimeout: exaLivecrawlTimeout,
 	})
+	tracing.End(span, err)
 	if err != nil {
-		return nil, err
+		return nil, failedWith(FailureFetch, err)
 	}
 
 	if err := exaValidateStatuses(articleLink, contents.Statuses); err != nil {
-		return nil, err
+		return nil, failedWith(FailureFetch, err)
 	}
 
 	if len(contents.Results) == 0 {
-		return nil, fmt.Errorf("exa contents: no results returned")
+		return nil, failedWith(FailureFetch, fmt.Errorf("exa contents: no results returned"))
 	}
 
 	res := contents.Results[0]
@@ -259,36 +931,104 @@ func extractArticleMetadata(articleLink string) (*types.Article, error) {
 	if err == nil {
 		extracted = parsed
 	} else {
-		parsed, ansErr := exaExtractViaAnswer(ctx, exaClient, articleLink)
+		if errors.Is(err, errExtractionTruncated) {
+			log.Printf("exa summary of %s was truncated, retrying with answer", articleLink)
+		}
+		answerCtx := retry.WithLimit(ctx, s.answerRetries)
+		parsed, ansErr := exaExtractViaAnswer(answerCtx, exaClient, articleLink, s.strictAuthor, s.autoTag)
 		if ansErr != nil {
-			return nil, fmt.Errorf("exa extraction failed: %w", ansErr)
+			// a truncated summary is the likelier cause than whatever the
+			// fallback ran into, so keep it visible
+			if errors.Is(err, errExtractionTruncated) && !errors.Is(ansErr, errExtractionTruncated) {
+				return nil, failedWith(FailureParse, fmt.Errorf("exa extraction failed: %w (answer fallback: %v)", err, ansErr))
+			}
+			return nil, failedWith(FailureParse, fmt.Errorf("exa extraction failed: %w", ansErr))
 		}
 		extracted = parsed
+		extractor = ExtractorExaAnswer
+	}
+
+	article := &types.Article{
+		Title:         strings.TrimSpace(extracted.Title),
+		Author:        strings.TrimSpace(extracted.Author),
+		Summary:       strings.TrimSpace(extracted.Summary),
+		DatePublished: strings.TrimSpace(extracted.DatePublished),
+		Type:          extracted.Type,
+		DateRead:      time.Now().Format("2006-01-02"),
+		Link:          articleLink,
+		Extractor:     extractor,
+		TypeUncertain: extracted.TypeConfidence != nil && *extracted.TypeConfidence < s.typeConfidenceThreshold,
+		Content:       strings.TrimSpace(res.Text),
+		ImagePath:     pagemeta.ResolveImage(articleLink, strings.TrimSpace(res.Image)),
+	}
+	if article.Type == types.TypeNotArticle {
+		return article, nil
+	}
+	if s.autoTag {
+		article.SuggestedTags = s.suggestTags(extracted.Tags)
 	}
 
-	title := strings.TrimSpace(extracted.Title)
-	author := strings.TrimSpace(extracted.Author)
-	summary := strings.TrimSpace(extracted.Summary)
-	published := strings.TrimSpace(extracted.DatePublished)
-	kind := extracted.Type
+	// on thin pages the model can commit to a type but leave fields empty;
+	// fill the gaps from the page's own meta tags
+	if len(s.incompleteFields(article)) > 0 {
+		s.fillFromPage(ctx, article)
+	}
+	s.guessAuthor(article, articleLink)
+	s.guessTitle(article, articleLink)
 
-	if author == "" {
-		author = fallbackAuthorFromURL(articleLink)
+	if missing := s.incompleteFields(article); len(missing) > 0 {
+		return nil, failedWith(FailureIncomplete, fmt.Errorf("exa extraction incomplete: missing %s", strings.Join(missing, ", ")))
 	}
+	return article, nil
+}
 
-	if title == "" || summary == "" {
-		return nil, fmt.Errorf("exa extraction incomplete: title=%t summary=%t", title != "", summary != "")
+// requiredFields lists the fields an extracted article of each type must
+// have before it is saved. Books often have no useful summary, while papers
+// are not much use without their authors.
+var requiredFields = map[int][]string{
+	types.TypeArticle: {"title", "summary"},
+	types.TypePaper:   {"title", "author", "summary"},
+	types.TypeBook:    {"title", "author"},
+}
+
+func missingFields(article *types.Article) []string {
+	values := map[string]string{
+		"title":   article.Title,
+		"author":  article.Author,
+		"summary": article.Summary,
+	}
+	required, ok := requiredFields[article.Type]
+	if !ok {
+		required = requiredFields[types.TypeArticle]
 	}
 
-	return &types.Article{
-		Title:         title,
-		Author:        author,
-		Summary:       summary,
-		DatePublished: published,
-		Type:          kind,
-		DateRead:      time.Now().Format("2006-01-02"),
-		Link:          articleLink,
-	}, nil
+	var missing []string
+	for _, field := range required {
+		if values[field] == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// incompleteFields lists the fields that keep an extracted article from
+// being saved: the required fields of its type, and with ON_PARTIAL=reject
+// also an author or title that had to be guessed and a missing publish date.
+func (s *Server) incompleteFields(article *types.Article) []string {
+	missing := missingFields(article)
+	if s.onPartial != PartialReject {
+		return missing
+	}
+	if (article.Author == "" || article.AuthorGuessed) && !slices.Contains(missing, "author") {
+		missing = append(missing, "author")
+	}
+	if article.TitleGuessed && !slices.Contains(missing, "title") {
+		missing = append(missing, "title")
+	}
+	if article.DatePublished == "" {
+		missing = append(missing, "datePublished")
+	}
+	return missing
 }
 
 type extractedArticleDetails struct {
@@ -297,6 +1037,10 @@ type extractedArticleDetails struct {
 	Summary       string `json:"summary"`
 	DatePublished string `json:"datePublished"`
 	Type          int    `json:"type"`
+	// TypeConfidence is nil when the model left it out.
+	TypeConfidence *float64 `json:"typeConfidence"`
+	// Tags are only asked for with AUTO_TAG.
+	Tags []string `json:"tags"`
 }
 
 func parseExtractedDetails(raw json.RawMessage) (*extractedArticleDetails, error) {
@@ -308,6 +1052,9 @@ func parseExtractedDetails(raw json.RawMessage) (*extractedArticleDetails, error
 	if raw[0] == '{' {
 		var out extractedArticleDetails
 		if err := json.Unmarshal(raw, &out); err != nil {
+			if jsonObjectTruncated(string(raw)) {
+				return nil, errExtractionTruncated
+			}
 			return nil, err
 		}
 		return &out, nil
@@ -321,6 +1068,9 @@ func parseExtractedDetails(raw json.RawMessage) (*extractedArticleDetails, error
 }
 
 func parseExtractedDetailsFromString(s string) (*extractedArticleDetails, error) {
+	if jsonObjectTruncated(s) {
+		return nil, errExtractionTruncated
+	}
 	obj, err := extractJSONObjectFromString(s)
 	if err != nil {
 		return nil, err
@@ -332,6 +1082,41 @@ func parseExtractedDetailsFromString(s string) (*extractedArticleDetails, error)
 	return &out, nil
 }
 
+// errExtractionTruncated is returned when the model's output stops before
+// its JSON object is closed, as happens when it runs out of output tokens
+// on long pages.
+var errExtractionTruncated = errors.New("extraction output truncated: the model stopped before finishing its JSON")
+
+// jsonObjectTruncated reports whether s opens a JSON object that is never
+// closed, skipping braces inside strings.
+func jsonObjectTruncated(s string) bool {
+	start := strings.Index(s, "{")
+	if start < 0 {
+		return false
+	}
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range s[start:] {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func extractJSONObjectFromString(s string) (string, error) {
 	start := strings.Index(s, "{")
 	end := strings.LastIndex(s, "}")
@@ -341,48 +1126,85 @@ func extractJSONObjectFromString(s string) (string, error) {
 	return s[start : end+1], nil
 }
 
-func exaExtractionRulesPrompt() string {
+// authorRule is the prompt rule for the author field. The strict one keeps
+// the model from passing a site or publication name off as the author.
+func authorRule(strict bool) string {
+	if strict {
+		return `author(s) exactly as named on the page, comma-separated if multiple; never infer them from the site, blog or domain name; if no author is named return "".`
+	}
+	return `author(s), comma-separated if multiple; if unknown return "".`
+}
+
+// tagsRule is the prompt rule for the tags suggested with AUTO_TAG.
+const tagsRule = `- tags: 3 to 5 short topical tags for the subject of the page, lowercase, e.g. "databases", "go", "machine learning".`
+
+func exaExtractionRulesPrompt(strictAuthor bool, autoTag bool) string {
 	// Keep this aligned with the DB fields. We still apply local guardrails (summary length/date format)
 	// even if the model drifts.
-	return strings.TrimSpace(`
+	rules := strings.TrimSpace(`
 Extract article metadata from the provided URL and return ONLY a single JSON object matching the provided JSON Schema.
 
 Rules:
 - title: full title.
-- author: author(s), comma-separated if multiple; if unknown return "".
+- author: ` + authorRule(strictAuthor) + `
 - summary: single sentence around 20 words or less.
 - datePublished: YYYY-MM-DD if possible; otherwise YYYY-MM; otherwise YYYY; otherwise "".
 - type: 0=article, 1=academic/research paper, 2=book, -1=not one of these.
+- typeConfidence: how sure you are of type, from 0 to 1.
 `)
+	if autoTag {
+		rules += "\n" + tagsRule
+	}
+	return rules
 }
 
-func exaExtractionSchema() map[string]any {
-	return map[string]any{
-		"$schema": "http://json-schema.org/draft-07/schema#",
-		"type":    "object",
-		"properties": map[string]any{
-			"title": map[string]any{
-				"type":        "string",
-				"description": "Full title of the article, book, or paper.",
-			},
-			"author": map[string]any{
-				"type":        "string",
-				"description": "Author(s). If multiple, comma-separated. If unknown, empty string.",
-			},
-			"summary": map[string]any{
-				"type":        "string",
-				"description": "Single sentence summary ~20 words or less.",
-			},
-			"datePublished": map[string]any{
-				"type":        "string",
-				"description": "YYYY-MM-DD if possible; otherwise YYYY-MM; otherwise YYYY; otherwise empty string.",
-			},
-			"type": map[string]any{
-				"type":        "integer",
-				"description": "0=article, 1=academic/research paper, 2=book, -1=not one of these.",
-			},
+func exaExtractionSchema(strictAuthor bool, autoTag bool) map[string]any {
+	authorDescription := "Author(s). If multiple, comma-separated. If unknown, empty string."
+	if strictAuthor {
+		authorDescription = "Author(s) named on the page, comma-separated if multiple. Never inferred from the site name. Empty string if none is named."
+	}
+
+	properties := map[string]any{
+		"title": map[string]any{
+			"type":        "string",
+			"description": "Full title of the article, book, or paper.",
+		},
+		"author": map[string]any{
+			"type":        "string",
+			"description": authorDescription,
+		},
+		"summary": map[string]any{
+			"type":        "string",
+			"description": "Single sentence summary ~20 words or less.",
+		},
+		"datePublished": map[string]any{
+			"type":        "string",
+			"description": "YYYY-MM-DD if possible; otherwise YYYY-MM; otherwise YYYY; otherwise empty string.",
 		},
-		"required": []string{"title", "author", "summary", "datePublished", "type"},
+		"type": map[string]any{
+			"type":        "integer",
+			"description": "0=article, 1=academic/research paper, 2=book, -1=not one of these.",
+		},
+		"typeConfidence": map[string]any{
+			"type":        "number",
+			"description": "Confidence in type, from 0 (guess) to 1 (certain).",
+		},
+	}
+	required := []string{"title", "author", "summary", "datePublished", "type", "typeConfidence"}
+	if autoTag {
+		properties["tags"] = map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "3 to 5 short lowercase topical tags for the subject of the page.",
+		}
+		required = append(required, "tags")
+	}
+
+	return map[string]any{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
 	}
 }
 
@@ -398,29 +1220,123 @@ func exaValidateStatuses(articleLink string, statuses []exa.ContentStatus) error
 	return nil
 }
 
-func exaExtractViaAnswer(ctx context.Context, exaClient *exa.Client, articleLink string) (*extractedArticleDetails, error) {
+func exaExtractViaAnswer(ctx context.Context, exaClient *exa.Client, articleLink string, strictAuthor bool, autoTag bool) (*extractedArticleDetails, error) {
+	ctx, span := tracing.Start(ctx, "exa.answer", attribute.String("article.link", articleLink))
 	answerResp, err := exaClient.Answer(ctx, exa.AnswerRequest{
-		Query: fmt.Sprintf(
-			`From this URL: %s
+		Query: exaAnswerPrompt(articleLink, strictAuthor, autoTag),
+		Text:  false,
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	return parseExtractedDetailsFromString(answerResp.Answer)
+}
+
+// exaAnswerPrompt is the query sent to Exa's answer endpoint when the
+// contents summary can't be parsed.
+func exaAnswerPrompt(articleLink string, strictAuthor bool, autoTag bool) string {
+	shape := `{"title": string, "author": string, "summary": string, "datePublished": string, "type": number, "typeConfidence": number}`
+	if autoTag {
+		shape = `{"title": string, "author": string, "summary": string, "datePublished": string, "type": number, "typeConfidence": number, "tags": [string]}`
+	}
+	prompt := fmt.Sprintf(
+		`From this URL: %s
 Return ONLY a single JSON object (no prose, no markdown fences) matching:
-{"title": string, "author": string, "summary": string, "datePublished": string, "type": number}
+%s
 
 Rules:
 - title: full title.
-- author: author(s), comma-separated if multiple; if unknown return "".
+- author: %s
 - summary: single sentence around 20 words or less.
 - datePublished: YYYY-MM-DD if possible; otherwise YYYY-MM; otherwise YYYY; otherwise "".
-- type: 0=article, 1=academic/research paper, 2=book, -1=not one of these.`,
-			articleLink,
-		),
-		Text: false,
-	})
+- type: 0=article, 1=academic/research paper, 2=book, -1=not one of these.
+- typeConfidence: how sure you are of type, from 0 to 1.`,
+		articleLink, shape, authorRule(strictAuthor),
+	)
+	if autoTag {
+		prompt += "\n" + tagsRule
+	}
+	return prompt
+}
+
+// guessAuthor fills an empty author from the link's domain and flags it as
+// guessed. With STRICT_AUTHOR the author is left empty instead.
+func (s *Server) guessAuthor(article *types.Article, link string) {
+	if article.Author != "" || s.strictAuthor {
+		return
+	}
+	article.Author = fallbackAuthorFromURL(link)
+	article.AuthorGuessed = article.Author != ""
+}
+
+// guessTitle fills an empty title from the link's path and flags it as
+// guessed, so an article the page gave no title still shows up in lists.
+// With TITLE_FROM_URL=false the title is left empty and the article fails
+// extraction as before.
+func (s *Server) guessTitle(article *types.Article, link string) {
+	if article.Title != "" || !s.titleFromURL {
+		return
+	}
+	article.Title = fallbackTitleFromURL(link)
+	article.TitleGuessed = article.Title != ""
+}
+
+// fallbackTitleFromURL turns the last readable segment of the link's path
+// into a title, e.g. /posts/how-to-write-go.html -> How To Write Go. Ids
+// such as the hash Medium appends to slugs are dropped.
+func fallbackTitleFromURL(link string) string {
+	u, err := url.Parse(link)
 	if err != nil {
-		return nil, err
+		return ""
 	}
-	return parseExtractedDetailsFromString(answerResp.Answer)
+	segments := strings.Split(u.Path, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment, err := url.PathUnescape(segments[i])
+		if err != nil {
+			segment = segments[i]
+		}
+		segment = strings.TrimSuffix(segment, path.Ext(segment))
+		words := strings.FieldsFunc(segment, func(r rune) bool {
+			return r == '-' || r == '_' || r == '+' || unicode.IsSpace(r)
+		})
+		if len(words) > 1 && isSlugID(words[len(words)-1]) {
+			words = words[:len(words)-1]
+		}
+		if countLetters(words) < 3 {
+			continue
+		}
+		for j, word := range words {
+			r, size := utf8.DecodeRuneInString(word)
+			words[j] = string(unicode.ToUpper(r)) + word[size:]
+		}
+		return strings.Join(words, " ")
+	}
+	return ""
 }
 
+// isSlugID reports whether a slug word is an id rather than a word: a run
+// of 8 or more hex digits with at least one digit in it.
+func isSlugID(word string) bool {
+	if len(word) < 8 || !strings.ContainsAny(word, "0123456789") {
+		return false
+	}
+	return strings.Trim(strings.ToLower(word), "0123456789abcdef") == ""
+}
+
+// countLetters counts the letters in words; segments with only a couple,
+// like /p/ or /v2, are no title.
+func countLetters(words []string) int {
+	n := 0
+	for _, word := range words {
+		for _, r := range word {
+			if unicode.IsLetter(r) {
+				n++
+			}
+		}
+	}
+	return n
+}
 
 func fallbackAuthorFromURL(link string) string {
 	u, err := url.Parse(link)
@@ -439,4 +1355,61 @@ func fallbackAuthorFromURL(link string) string {
 		return parts[len(parts)-2]
 	}
 	return host
-}
\ No newline at end of file
+}
+
+// GetArticleSitesHandler lists the sites articles were saved from with how
+// many came from each, for browsing by publication with ?site.
+func (s *Server) GetArticleSitesHandler(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.db.GetSiteCounts()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	render.Respond(w, r, counts)
+}
+
+// GetArticleTypesHandler lists every known article type with its label and
+// how many stored articles have it.
+func (s *Server) GetArticleTypesHandler(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.db.GetTypeCounts()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	byType := make(map[int]int, len(counts))
+	for _, c := range counts {
+		byType[c.Type] = c.Count
+	}
+
+	resp := make([]types.TypeCount, 0, len(types.KnownTypes))
+	for _, t := range types.KnownTypes {
+		resp = append(resp, types.TypeCount{Type: t, Label: types.TypeLabels[t], Count: byType[t]})
+	}
+	render.Respond(w, r, resp)
+}
+
+type ArticleStatsResponse struct {
+	*types.ArticleStats
+}
+
+func (rd *ArticleStatsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetArticleStatsHandler returns the totals behind a reading dashboard: how
+// many articles there are of each type, how many were read this month and
+// the span of their read dates.
+func (s *Server) GetArticleStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.db.GetArticleStats()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = render.Render(w, r, &ArticleStatsResponse{stats})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
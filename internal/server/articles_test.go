@@ -0,0 +1,254 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reading-list-api/internal/types"
+	"strconv"
+	"testing"
+)
+
+func TestCreateArticleSkipExtraction(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	body := `{"articleLink": "https://example.com/post?utm_source=feed", "title": "A post", "summary": "What it says", "author": "Ada"}`
+
+	w := serve(s, http.MethodPost, "/articles?skipExtraction=true", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /articles = %d: %s", w.Code, w.Body)
+	}
+	var resp ArticleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Article == nil || resp.ID == 0 {
+		t.Fatalf("response has no article id: %s", w.Body)
+	}
+	if got := w.Header().Get(ExtractorHeader); got != ExtractorClient {
+		t.Errorf("%s = %q, want %q", ExtractorHeader, got, ExtractorClient)
+	}
+
+	stored, err := store.GetArticleByID(resp.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.Title != "A post" || stored.Author != "Ada" || stored.Summary != "What it says" {
+		t.Errorf("stored %q by %q: %q, want the metadata sent", stored.Title, stored.Author, stored.Summary)
+	}
+	if stored.Link != "https://example.com/post" {
+		t.Errorf("stored link %q, want it normalized", stored.Link)
+	}
+	if stored.Status != types.StatusUnread || stored.ExtractionStatus != types.ExtractionComplete {
+		t.Errorf("stored %s and %s, want unread and complete", stored.Status, stored.ExtractionStatus)
+	}
+
+	if attempts, _ := s.extractionStats.snapshot(); attempts != 0 {
+		t.Errorf("client metadata counted as %d extraction attempts", attempts)
+	}
+
+	w = serve(s, http.MethodPost, "/articles?skipExtraction=true", body)
+	if w.Code != http.StatusConflict {
+		t.Errorf("saving the link again = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateArticleSkipExtractionRequiresMetadata(t *testing.T) {
+	s, store := newTestServer(t, nil)
+
+	w := serve(s, http.MethodPost, "/articles?skipExtraction=true", `{"articleLink": "https://example.com/post"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST /articles without a title = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if maxID, _ := store.GetMaxArticleID(); maxID != 0 {
+		t.Errorf("article %d was saved", maxID)
+	}
+}
+
+func TestGetArticlesPageHandler(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	for i := 1; i <= 12; i++ {
+		insertTestArticle(t, store, "article-"+strconv.Itoa(i))
+	}
+	tagged := insertTestArticle(t, store, "tagged")
+	if err := store.AddTags(tagged.ID, []string{"Go"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	insertTestArticle(t, store, "pending", func(a *types.Article) {
+		a.ExtractionStatus = types.ExtractionPending
+	})
+
+	tests := []struct {
+		target string
+		total  int
+		listed int
+	}{
+		{"/articles", 13, 10},
+		{"/articles?page=2", 13, 3},
+		{"/articles?page=3", 13, 0},
+		{"/articles?tag=go", 1, 1},
+		{"/articles?status=pending", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			w := serve(s, http.MethodGet, tt.target, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d: %s", tt.target, w.Code, w.Body)
+			}
+			var resp ArticlePageResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if resp.TotalArticles != tt.total {
+				t.Errorf("totalArticles = %d, want %d", resp.TotalArticles, tt.total)
+			}
+			if got := w.Header().Get(TotalCountHeader); got != strconv.Itoa(tt.total) {
+				t.Errorf("%s = %q, want %d", TotalCountHeader, got, tt.total)
+			}
+			if len(resp.Articles) != tt.listed {
+				t.Errorf("listed %d articles, want %d", len(resp.Articles), tt.listed)
+			}
+			if got := w.Header().Get(SnapshotHeader); got != "14" {
+				t.Errorf("%s = %q, want the highest id, 14", SnapshotHeader, got)
+			}
+		})
+	}
+}
+
+func TestGetArticlesPageHandlerSnapshot(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	for i := 1; i <= 3; i++ {
+		insertTestArticle(t, store, "article-"+strconv.Itoa(i))
+	}
+	w := serve(s, http.MethodGet, "/articles", "")
+	snapshot := w.Header().Get(SnapshotHeader)
+	insertTestArticle(t, store, "later")
+
+	w = serve(s, http.MethodGet, "/articles?snapshot="+snapshot, "")
+	var resp ArticlePageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.TotalArticles != 3 {
+		t.Errorf("totalArticles = %d inside snapshot %s, want 3", resp.TotalArticles, snapshot)
+	}
+}
+
+func TestGetArticlesPageHandlerCursor(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	for i := 1; i <= 25; i++ {
+		insertTestArticle(t, store, "article-"+strconv.Itoa(i))
+	}
+
+	seen := make(map[int]bool)
+	target := "/articles?cursor="
+	for pages := 0; target != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("still paging after %d pages", pages)
+		}
+		w := serve(s, http.MethodGet, target, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", target, w.Code, w.Body)
+		}
+		var resp ArticlePageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		for _, article := range resp.Articles {
+			if seen[article.ID] {
+				t.Errorf("article %d listed twice", article.ID)
+			}
+			seen[article.ID] = true
+		}
+		target = ""
+		if resp.NextCursor != "" {
+			target = "/articles?cursor=" + resp.NextCursor
+		}
+	}
+	if len(seen) != 25 {
+		t.Errorf("listed %d articles, want 25", len(seen))
+	}
+}
+
+func TestArticleResponsesCarryTags(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	untagged := insertTestArticle(t, store, "untagged")
+	tagged := insertTestArticle(t, store, "tagged")
+	if err := store.AddTags(tagged.ID, []string{"go", "db"}); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		target string
+		body   string
+		want   string
+	}{
+		{http.MethodGet, "/articles/" + strconv.Itoa(untagged.ID), "", `[]`},
+		{http.MethodGet, "/articles/" + strconv.Itoa(tagged.ID), "", `["db","go"]`},
+		{http.MethodPatch, "/articles/" + strconv.Itoa(tagged.ID) + "/pin", "", `["db","go"]`},
+		{http.MethodPost, "/articles/by-ids", `{"ids": [` + strconv.Itoa(tagged.ID) + `]}`, `["db","go"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := serve(s, tt.method, tt.target, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s %s = %d: %s", tt.method, tt.target, w.Code, w.Body)
+			}
+			data := w.Body.Bytes()
+			if data[0] == '[' {
+				var list []json.RawMessage
+				if err := json.Unmarshal(data, &list); err != nil || len(list) != 1 {
+					t.Fatalf("want a list of one article: %s", data)
+				}
+				data = list[0]
+			}
+			var resp struct {
+				Tags json.RawMessage `json:"tags"`
+			}
+			if err := json.Unmarshal(data, &resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if string(resp.Tags) != tt.want {
+				t.Errorf("tags = %s, want %s", resp.Tags, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadArticleByIDHandler(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	article := insertTestArticle(t, store, "post")
+	target := "/articles/" + strconv.Itoa(article.ID)
+
+	get := serve(s, http.MethodGet, target, "")
+	etag := get.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET sent no ETag")
+	}
+
+	w := serve(s, http.MethodHead, target, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("HEAD = %d: %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("ETag"); got != etag {
+		t.Errorf("HEAD ETag = %s, want GET's %s", got, etag)
+	}
+	if w.Header().Get(ExtractorHeader) != get.Header().Get(ExtractorHeader) {
+		t.Errorf("HEAD %s = %q, want GET's %q", ExtractorHeader, w.Header().Get(ExtractorHeader), get.Header().Get(ExtractorHeader))
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("HEAD sent a body: %s", w.Body)
+	}
+
+	req := httptest.NewRequest(http.MethodHead, target, nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("HEAD with the current ETag = %d, want 304", w.Code)
+	}
+
+	if w := serve(s, http.MethodHead, "/articles/999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("HEAD of a missing article = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"log"
+	"reading-list-api/internal/types"
+	"strings"
+	"unicode"
+)
+
+// maxSuggestedTags caps the tags kept from one AUTO_TAG extraction.
+const maxSuggestedTags = 5
+
+// tagAliases maps spellings of a topic to the key of its usual tag, so a
+// suggested "golang" joins an existing "go".
+var tagAliases = map[string]string{
+	"golang":        "go",
+	"js":            "javascript",
+	"ts":            "typescript",
+	"py":            "python",
+	"ml":            "machinelearning",
+	"ai":            "artificialintelligence",
+	"llm":           "largelanguagemodel",
+	"k8s":           "kubernetes",
+	"postgres":      "postgresql",
+	"db":            "database",
+	"infosec":       "security",
+	"cybersecurity": "security",
+}
+
+// tagKey reduces a tag to what near-duplicates share: lowercase letters and
+// digits only, without a plural s, resolved through tagAliases.
+func tagKey(tag string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	key := b.String()
+	if len(key) > 3 && strings.HasSuffix(key, "s") && !strings.HasSuffix(key, "ss") {
+		key = strings.TrimSuffix(key, "s")
+	}
+	if alias, ok := tagAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// suggestTags normalizes the tags the model suggested and reconciles them
+// with the tags already in use: a suggestion that is a near-duplicate of an
+// existing tag is replaced by it. At most maxSuggestedTags are kept.
+func (s *Server) suggestTags(suggested []string) types.TagList {
+	existing := make(map[string]string)
+	names, err := s.store.GetAllTags()
+	if err != nil {
+		log.Printf("error listing tags to reconcile suggestions: %v", err)
+	}
+	for _, name := range names {
+		if _, ok := existing[tagKey(name)]; !ok {
+			existing[tagKey(name)] = name
+		}
+	}
+
+	tags := make(types.TagList, 0, maxSuggestedTags)
+	seen := make(map[string]bool)
+	for _, tag := range suggested {
+		tag = strings.Join(strings.Fields(strings.ToLower(strings.TrimLeft(tag, "# "))), " ")
+		// tags are stored comma-separated
+		tag = strings.ReplaceAll(tag, ",", "")
+		key := tagKey(tag)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if name, ok := existing[key]; ok {
+			tag = name
+		}
+		tags = append(tags, tag)
+		if len(tags) == maxSuggestedTags {
+			break
+		}
+	}
+	return tags
+}
+
+// applySuggestedTags tags a stored article with its suggested tags.
+func (s *Server) applySuggestedTags(article *types.Article) {
+	if len(article.SuggestedTags) == 0 {
+		return
+	}
+	if err := s.store.AddTags(article.ID, article.SuggestedTags); err != nil {
+		log.Printf("error tagging article %d with suggested tags: %v", article.ID, err)
+	}
+}
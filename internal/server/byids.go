@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reading-list-api/internal/types"
+
+	"github.com/go-chi/render"
+)
+
+// maxByIDs caps the ids hydrated by one POST /articles/by-ids.
+const maxByIDs = 500
+
+type ArticlesByIDsRequest struct {
+	IDs []int `json:"ids"`
+}
+
+func (a *ArticlesByIDsRequest) Bind(r *http.Request) error {
+	if a.IDs == nil {
+		return errors.New("ids is required")
+	}
+	if len(a.IDs) > maxByIDs {
+		return fmt.Errorf("at most %d ids can be fetched at once", maxByIDs)
+	}
+	return nil
+}
+
+// GetArticlesByIDsHandler returns the articles with the given ids in the
+// order they were asked for, so a client can hydrate a list it ranked
+// itself. Ids with no article are left out.
+func (s *Server) GetArticlesByIDsHandler(w http.ResponseWriter, r *http.Request) {
+	data := &ArticlesByIDsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	found, err := s.store.GetArticlesByIDs(data.IDs)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	byID := make(map[int]types.Article, len(*found))
+	for _, article := range *found {
+		byID[article.ID] = article
+	}
+
+	articles := make([]types.Article, 0, len(data.IDs))
+	for _, id := range data.IDs {
+		if article, ok := byID[id]; ok {
+			articles = append(articles, article)
+		}
+	}
+
+	s.renderArticleList(w, r, &articles)
+}
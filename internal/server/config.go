@@ -0,0 +1,88 @@
+package server
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envInt reads an integer setting from the environment, falling back to def
+// when it is unset or malformed.
+func envInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %d", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envBool reads a boolean setting from the environment, falling back to def
+// when it is unset or malformed.
+func envBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %t", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// envFloat reads a decimal setting from the environment, falling back to def
+// when it is unset or malformed.
+func envFloat(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		log.Printf("invalid %s=%q, using default %g", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// Partial extraction policies, picked with ON_PARTIAL. save stores an
+// extracted article once the fields required for its type are filled, and
+// reject also refuses it when its author or publish date is missing.
+const (
+	PartialSave   = "save"
+	PartialReject = "reject"
+)
+
+func onPartial() string {
+	switch policy := os.Getenv("ON_PARTIAL"); policy {
+	case "":
+		return PartialSave
+	case PartialSave, PartialReject:
+		return policy
+	default:
+		log.Printf("invalid ON_PARTIAL=%q, using %s", policy, PartialSave)
+		return PartialSave
+	}
+}
+
+// insecureHosts reads ALLOW_INSECURE_HOSTS, the comma-separated hosts whose
+// TLS certificates the page fetcher does not verify.
+func insecureHosts() []string {
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(os.Getenv("ALLOW_INSECURE_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) > 0 {
+		log.Printf("skipping TLS verification when fetching from %s", strings.Join(hosts, ", "))
+	}
+	return hosts
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reading-list-api/internal/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// csvColumns is the column layout of GET /articles/export.csv, which
+// POST /articles/import.csv reads back.
+var csvColumns = []string{
+	"id", "link", "title", "author", "summary", "type", "date_read", "date_published",
+	"status", "created_at", "completed_at", "pinned", "site_name", "rating", "notes",
+	"tags",
+}
+
+// ExportCSVHandler writes every article, with its tags, as CSV.
+func (s *Server) ExportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	articles, err := s.store.GetAllArticles(s.defaultOrder)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	tagsByArticle, err := s.store.GetTagsByArticle()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="articles.csv"`)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		log.Printf("error writing csv export: %v", err)
+		return
+	}
+	for _, article := range *articles {
+		tags := tagsByArticle[article.ID]
+		err = cw.Write([]string{
+			strconv.Itoa(article.ID),
+			article.Link,
+			article.Title,
+			article.Author,
+			article.Summary,
+			strconv.Itoa(article.Type),
+			article.DateRead,
+			article.DatePublished,
+			article.Status,
+			article.CreatedAt,
+			article.CompletedAt,
+			strconv.FormatBool(article.Pinned),
+			article.SiteName,
+			csvRating(article.Rating),
+			article.Notes,
+			strings.Join(tags, ","),
+		})
+		if err != nil {
+			log.Printf("error writing csv export: %v", err)
+			return
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("error writing csv export: %v", err)
+	}
+}
+
+// csvRating writes a rating, empty when the article is unrated.
+func csvRating(rating *int) string {
+	if rating == nil {
+		return ""
+	}
+	return strconv.Itoa(*rating)
+}
+
+// csvRow is a parsed data row.
+type csvRow struct {
+	article *types.Article
+	tags    []string
+}
+
+// ImportCSVHandler restores articles from a CSV in the export layout. By
+// default the CSV's metadata is stored as-is; with ?extract=true the rows
+// are saved as pending and re-extracted. Rows are inserted in one
+// transaction: if any row is invalid nothing is imported and every bad row
+// is reported with its line number. Links already saved are skipped, keeping
+// their stored rating and notes.
+func (s *Server) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	extract := r.URL.Query().Get("extract") == "true"
+	if extract {
+		if errResp := s.requireServerFetch(); errResp != nil {
+			render.Render(w, r, errResp)
+			return
+		}
+	}
+
+	body, err := importBody(w, r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	defer body.Close()
+
+	rows, rowErrors, err := s.parseArticlesCSV(body, extract)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	resp := &ImportResponse{Errors: rowErrors}
+	articles := make([]*types.Article, 0, len(rows))
+	tags := make([][]string, 0, len(rows))
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		exists, err := s.store.ArticleExists(row.article.Link)
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		if exists || seen[row.article.Link] {
+			resp.Skipped++
+			continue
+		}
+		seen[row.article.Link] = true
+		articles = append(articles, row.article)
+		tags = append(tags, row.tags)
+	}
+
+	if s.maxArticles > 0 {
+		total, err := s.librarySize()
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		if total+len(articles) > s.maxArticles {
+			resp.Errors = append(resp.Errors, ImportError{Error: fmt.Sprintf("library full: importing %d articles would pass the limit of %d", len(articles), s.maxArticles)})
+		}
+	}
+
+	if len(resp.Errors) > 0 {
+		resp.Failed = len(resp.Errors)
+		resp.Skipped = 0
+		resp.status = http.StatusBadRequest
+		render.Render(w, r, resp)
+		return
+	}
+
+	if err := s.store.InsertArticlesTx(r.Context(), articles, tags); err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	resp.Imported = len(articles)
+	resp.status = http.StatusCreated
+	if extract {
+		resp.status = http.StatusAccepted
+		for _, article := range articles {
+			s.dispatchExtraction(article.ID)
+		}
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// parseArticlesCSV reads the header and every data row. A header with
+// unknown or repeated columns, or without link, is an error; bad rows are
+// returned as ImportErrors.
+func (s *Server) parseArticlesCSV(body io.Reader, extract bool) ([]csvRow, []ImportError, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty csv")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading csv header: %v", err)
+	}
+	known := make(map[string]bool, len(csvColumns))
+	for _, column := range csvColumns {
+		known[column] = true
+	}
+	index := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !known[column] {
+			return nil, nil, fmt.Errorf("unknown csv column %q, expected %s", column, strings.Join(csvColumns, ", "))
+		}
+		if _, ok := index[column]; ok {
+			return nil, nil, fmt.Errorf("csv column %q appears twice", column)
+		}
+		index[column] = i
+	}
+	if _, ok := index["link"]; !ok {
+		return nil, nil, errors.New("csv header has no link column")
+	}
+
+	rows := make([]csvRow, 0)
+	rowErrors := make([]ImportError, 0)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := cr.FieldPos(0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading csv: %v", err)
+		}
+		field := func(column string) string {
+			i, ok := index[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := s.csvArticle(field, extract)
+		if err != nil {
+			rowErrors = append(rowErrors, ImportError{Line: line, Link: field("link"), Error: err.Error()})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// csvArticle builds the article for one row.
+func (s *Server) csvArticle(field func(string) string, extract bool) (csvRow, error) {
+	link, err := normalizeURL(field("link"))
+	if err == nil && !strings.HasPrefix(link, "http") {
+		err = errors.New("not an http(s) link")
+	}
+	if err == nil {
+		err = checkLinkInput(link)
+	}
+	if err != nil {
+		return csvRow{}, err
+	}
+
+	article := &types.Article{
+		Title:         field("title"),
+		Author:        field("author"),
+		Summary:       field("summary"),
+		DateRead:      field("date_read"),
+		DatePublished: field("date_published"),
+		Link:          link,
+		OriginalLink:  field("link"),
+		PaperID:       paperIDFromLink(link),
+		SiteName:      field("site_name"),
+		Notes:         field("notes"),
+		Status:        field("status"),
+		CreatedAt:     field("created_at"),
+		CompletedAt:   field("completed_at"),
+		Extractor:     ExtractorClient,
+	}
+	if raw := field("type"); raw != "" {
+		article.Type, err = strconv.Atoi(raw)
+		if _, ok := types.TypeLabels[article.Type]; err != nil || !ok {
+			return csvRow{}, fmt.Errorf("invalid type %q, must be 0 (article), 1 (paper) or 2 (book)", raw)
+		}
+	}
+	if article.Status != "" && !types.ValidStatus(article.Status) {
+		return csvRow{}, fmt.Errorf("invalid status %q, must be one of unread, reading, read", article.Status)
+	}
+	if raw := field("pinned"); raw != "" {
+		article.Pinned, err = strconv.ParseBool(raw)
+		if err != nil {
+			return csvRow{}, fmt.Errorf("invalid pinned %q", raw)
+		}
+	}
+	if raw := field("rating"); raw != "" {
+		rating, err := strconv.Atoi(raw)
+		if err != nil || rating < 1 || rating > maxRating {
+			return csvRow{}, fmt.Errorf("invalid rating %q, must be between 1 and %d", raw, maxRating)
+		}
+		article.Rating = &rating
+	}
+	if article.DateRead != "" {
+		if _, err := time.Parse("2006-01-02", article.DateRead); err != nil {
+			return csvRow{}, fmt.Errorf("invalid date_read %q, must be YYYY-MM-DD", article.DateRead)
+		}
+	} else {
+		article.DateRead = time.Now().Format("2006-01-02")
+	}
+	for _, column := range []string{"created_at", "completed_at"} {
+		if raw := field(column); raw != "" {
+			if _, err := time.Parse(time.RFC3339, raw); err != nil {
+				return csvRow{}, fmt.Errorf("invalid %s %q, must be an RFC 3339 timestamp", column, raw)
+			}
+		}
+	}
+
+	if article.SiteName == "" {
+		article.SiteName = siteNameFromLink(link)
+	}
+	if extract {
+		article.ExtractionStatus = types.ExtractionPending
+		article.Extractor = ""
+	} else {
+		if missing := missingFields(article); len(missing) > 0 {
+			return csvRow{}, fmt.Errorf("missing %s", strings.Join(missing, ", "))
+		}
+		s.sanitizeArticle(article)
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(field("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return csvRow{article: article, tags: tags}, nil
+}
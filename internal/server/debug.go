@@ -0,0 +1,94 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/render"
+)
+
+// maxPreviewMarkdownBytes caps the page markdown returned by the extract
+// preview.
+const maxPreviewMarkdownBytes = 100 << 10 // 100KB
+
+type ExtractPreviewRequest struct {
+	Link string `json:"link"`
+}
+
+func (a *ExtractPreviewRequest) Bind(r *http.Request) error {
+	if strings.TrimSpace(a.Link) == "" {
+		return errors.New("link is required")
+	}
+	return nil
+}
+
+type ExtractPreviewResponse struct {
+	Link           string         `json:"link"`
+	FetchStrategy  string         `json:"fetchStrategy"`
+	Title          string         `json:"title"`
+	Markdown       string         `json:"markdown"`
+	MarkdownBytes  int            `json:"markdownBytes"`
+	Truncated      bool           `json:"truncated"`
+	SummaryQuery   string         `json:"summaryQuery"`
+	SummarySchema  map[string]any `json:"summarySchema"`
+	FallbackPrompt string         `json:"fallbackPrompt"`
+}
+
+func (rd *ExtractPreviewResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ExtractPreviewHandler shows what an extraction of link would work from:
+// the page as fetched and converted to markdown, and the prompts sent to
+// Exa. Exa is not asked for a summary, so nothing is spent on the model.
+func (s *Server) ExtractPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	data := &ExtractPreviewRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	link, err := normalizeURL(data.Link)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	page, err := s.fetchPage(r.Context(), link)
+	if err != nil {
+		render.Render(w, r, ErrBadGateway(err))
+		return
+	}
+
+	resp := &ExtractPreviewResponse{
+		Link:           link,
+		FetchStrategy:  s.fetchStrategy,
+		Title:          page.Title,
+		Markdown:       page.Markdown,
+		MarkdownBytes:  len(page.Markdown),
+		SummaryQuery:   exaExtractionRulesPrompt(s.strictAuthor, s.autoTag),
+		SummarySchema:  exaExtractionSchema(s.strictAuthor, s.autoTag),
+		FallbackPrompt: exaAnswerPrompt(link, s.strictAuthor, s.autoTag),
+	}
+	if len(resp.Markdown) > maxPreviewMarkdownBytes {
+		cut := maxPreviewMarkdownBytes
+		for cut > 0 && !utf8.RuneStart(resp.Markdown[cut]) {
+			cut--
+		}
+		resp.Markdown = resp.Markdown[:cut]
+		resp.Truncated = true
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -1,7 +1,15 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/render"
 )
@@ -38,6 +46,74 @@ func ErrInvalidRequest(err error) render.Renderer {
 	}
 }
 
+// ErrBind reports a failed render.Bind, telling a malformed JSON body apart
+// from a well-formed body that fails validation.
+func ErrBind(err error) render.Renderer {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var validationErr *ValidationError
+	switch {
+	case errors.Is(err, io.EOF):
+		return ErrInvalidRequest(errors.New("empty request body"))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrInvalidRequest(errors.New("invalid JSON body: unexpected end of input"))
+	case errors.As(err, &syntaxErr):
+		return ErrInvalidRequest(fmt.Errorf("invalid JSON body: %v (at offset %d)", syntaxErr, syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		return ErrInvalidRequest(fmt.Errorf("invalid JSON body: field %q must be %s (at offset %d)", typeErr.Field, typeErr.Type, typeErr.Offset))
+	case errors.As(err, &validationErr):
+		return validationErr
+	}
+	return ErrInvalidRequest(err)
+}
+
+// ValidationError lists the request fields that failed validation, keyed by
+// their JSON names, so a client can point at the offending input. Bind
+// returns it and ErrBind renders it as a 400.
+type ValidationError struct {
+	StatusText string            `json:"status"`
+	ErrorText  string            `json:"error"`
+	Fields     map[string]string `json:"fields"`
+}
+
+// invalidField records what is wrong with field, keeping the first problem
+// found for each.
+func (e *ValidationError) invalidField(field string, problem string) {
+	if e.Fields == nil {
+		e.Fields = map[string]string{}
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = problem
+	}
+}
+
+// orNil returns nil when no field failed, so Bind can return it directly.
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	e.StatusText = "Invalid request"
+	e.ErrorText = "validation"
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	for i, field := range fields {
+		fields[i] = field + ": " + e.Fields[field]
+	}
+	return "invalid " + strings.Join(fields, ", ")
+}
+
+func (e *ValidationError) Render(w http.ResponseWriter, r *http.Request) error {
+	render.Status(r, http.StatusBadRequest)
+	return nil
+}
+
 func ErrInternalServer(err error) render.Renderer {
 	return &ErrResponse{
 		Err:            err,
@@ -47,9 +123,102 @@ func ErrInternalServer(err error) render.Renderer {
 	}
 }
 
+func ErrForbidden(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 403,
+		StatusText:     "Forbidden",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrConflict(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 409,
+		StatusText:     "Conflict",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrUnsupportedMediaType(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 415,
+		StatusText:     "Unsupported Media Type",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrNotImplemented(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 501,
+		StatusText:     "Not Implemented",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrBadGateway(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 502,
+		StatusText:     "Bad Gateway",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrGatewayTimeout(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 504,
+		StatusText:     "Gateway Timeout",
+		ErrorText:      err.Error(),
+	}
+}
+
 func ErrNotFound() render.Renderer {
 	return &ErrResponse{
 		HTTPStatusCode: 404,
 		StatusText:     "Resource not found",
 	}
 }
+
+func ErrUnauthorized(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 401,
+		StatusText:     "Unauthorized",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrServiceUnavailable(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 503,
+		StatusText:     "Service Unavailable",
+		ErrorText:      err.Error(),
+	}
+}
+
+// retryAfterResponse is an error response that also tells the client, in
+// Retry-After, when to try again.
+type retryAfterResponse struct {
+	*ErrResponse
+	after time.Duration
+}
+
+func (rd *retryAfterResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Retry-After", strconv.Itoa(int(rd.after.Seconds())))
+	return rd.ErrResponse.Render(w, r)
+}
+
+// ErrRetryLater is a 503 asking the client to come back after the given
+// wait.
+func ErrRetryLater(err error, after time.Duration) render.Renderer {
+	return &retryAfterResponse{
+		ErrResponse: ErrServiceUnavailable(err).(*ErrResponse),
+		after:       after,
+	}
+}
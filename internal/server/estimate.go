@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/render"
+)
+
+// charsPerToken approximates how many characters of English text make up
+// a model token; close enough for an estimate without a tokenizer.
+const charsPerToken = 4
+
+type EstimateRequest struct {
+	Link string `json:"articleLink"`
+}
+
+func (a *EstimateRequest) Bind(r *http.Request) error {
+	if strings.TrimSpace(a.Link) == "" {
+		return errors.New("articleLink is required")
+	}
+	if err := checkLinkInput(a.Link); err != nil {
+		return err
+	}
+	return nil
+}
+
+type EstimateResponse struct {
+	Link          string `json:"link"`
+	MarkdownBytes int    `json:"markdownBytes"`
+	// PageTokens covers the whole page; Exa only reads the first
+	// exaMaxTextCharacters of it, which InputTokens counts with the prompt.
+	PageTokens    int  `json:"pageTokens"`
+	InputTokens   int  `json:"inputTokens"`
+	TextTruncated bool `json:"textTruncated"`
+	// EstimatedCost is in USD, set only when EXTRACT_PRICE_PER_MTOK is.
+	EstimatedCost *float64 `json:"estimatedCost,omitempty"`
+}
+
+func (rd *EstimateResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// EstimateExtractionHandler fetches an article and estimates the tokens an
+// extraction of it would send, without asking Exa for a summary. Exa
+// publishes no per-token pricing to read, so the cost is only given when
+// EXTRACT_PRICE_PER_MTOK sets a price per million input tokens.
+func (s *Server) EstimateExtractionHandler(w http.ResponseWriter, r *http.Request) {
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	data := &EstimateRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	link, err := normalizeURL(data.Link)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	page, err := s.fetchPage(r.Context(), link)
+	if err != nil {
+		render.Render(w, r, ErrBadGateway(err))
+		return
+	}
+
+	schema, err := json.Marshal(exaExtractionSchema(s.strictAuthor, s.autoTag))
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	pageChars := utf8.RuneCountInString(page.Markdown)
+	readChars := min(pageChars, exaMaxTextCharacters)
+	promptChars := utf8.RuneCountInString(exaExtractionRulesPrompt(s.strictAuthor, s.autoTag)) + len(schema)
+
+	resp := &EstimateResponse{
+		Link:          link,
+		MarkdownBytes: len(page.Markdown),
+		PageTokens:    estimateTokens(pageChars),
+		InputTokens:   estimateTokens(readChars + promptChars),
+		TextTruncated: pageChars > exaMaxTextCharacters,
+	}
+	if s.extractPricePerMTok > 0 {
+		cost := math.Round(float64(resp.InputTokens)*s.extractPricePerMTok) / 1e6
+		resp.EstimatedCost = &cost
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+func estimateTokens(chars int) int {
+	return (chars + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// articleETag is a strong validator for an article, a hash of every field it
+// is rendered with, tags included, so any change to it gives a new tag. The
+// sparse and full renderings of an article are different bodies, so sparse
+// is part of the hash too.
+func articleETag(resp *ArticleResponse, sparse bool) (string, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	if sparse {
+		data = append(data, " sparse"...)
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatches reports whether an If-None-Match header names etag, either in
+// its list of tags or with *. Weak tags compare by their opaque part, as
+// If-None-Match uses the weak comparison.
+func etagMatches(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag of the article as rendered for r and, when the
+// request's If-None-Match already names it, answers 304 and reports true so
+// the handler can skip the body.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request, resp *ArticleResponse) (bool, error) {
+	etag, err := articleETag(resp, s.wantsSparse(r))
+	if err != nil {
+		return false, err
+	}
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,405 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/retry"
+	"reading-list-api/internal/types"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultExtractWorkers     = 2
+	defaultExtractQueueSize   = 100
+	defaultExtractMaxAttempts = 3
+	defaultExtractMaxRetries  = 5
+	defaultSyncExtractLimit   = 8
+
+	// queueFullRetryAfter is what clients are told to wait when the
+	// extraction queue is full.
+	queueFullRetryAfter = 30 * time.Second
+
+	// pendingPollInterval is how often pending articles that missed the
+	// queue are dispatched again.
+	pendingPollInterval = time.Minute
+)
+
+// enqueueArticle stores link as a pending article and answers 202 with the
+// record, leaving extraction to the background workers. The cheap checks
+// still run up front so obvious rejects are not accepted. With ?upsert=true
+// an existing article is queued for re-extraction instead, and stays listed
+// with its current metadata until that completes. Concurrent
+// requests for the same link share one queued article.
+func (s *Server) enqueueArticle(w http.ResponseWriter, r *http.Request, originalLink string) {
+	articleLink, err := normalizeURL(originalLink)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	maxAttempts, err := s.requestMaxAttempts(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	upsert := r.URL.Query().Get("upsert") == "true"
+	key := fmt.Sprintf("%t|%s", upsert, articleLink)
+	article, errResp := s.queueing.do(r.Context(), key, func() (*types.Article, render.Renderer) {
+		return s.queueArticle(articleLink, originalLink, upsert, maxAttempts)
+	})
+	if errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	s.renderArticle(w, r, article)
+}
+
+func (s *Server) queueArticle(articleLink string, originalLink string, upsert bool, maxAttempts int) (*types.Article, render.Renderer) {
+	existing, err := s.store.GetArticleByLink(articleLink)
+	if err != nil && !errors.Is(err, database.ErrArticleNotFound) {
+		return nil, ErrInternalServer(err)
+	}
+	if existing != nil && !upsert {
+		return nil, ErrConflict(database.ErrArticleExists)
+	}
+
+	if conflict, err := s.paperConflict(paperIDFromLink(articleLink), articleLink); err != nil {
+		return nil, ErrInternalServer(err)
+	} else if conflict != nil {
+		return nil, conflict
+	}
+
+	// shed load rather than pile up pending work the workers can't reach
+	if s.extractQueueFull() {
+		return nil, ErrRetryLater(errors.New("extraction queue is full, try again later"), queueFullRetryAfter)
+	}
+
+	var id int
+	if existing != nil {
+		id = existing.ID
+		err = s.store.QueueExtraction(id, maxAttempts)
+	} else {
+		if errResp := s.checkLibraryFull(); errResp != nil {
+			return nil, errResp
+		}
+		article := &types.Article{
+			DateRead:         time.Now().Format("2006-01-02"),
+			Link:             articleLink,
+			OriginalLink:     originalLink,
+			PaperID:          paperIDFromLink(articleLink),
+			ExtractionStatus: types.ExtractionPending,
+
+			ExtractionMaxAttempts: maxAttempts,
+		}
+		err = s.store.InsertArticle(article)
+		id = article.ID
+	}
+	if errors.Is(err, database.ErrArticleExists) {
+		return nil, ErrConflict(err)
+	}
+	if err != nil {
+		return nil, ErrInternalServer(err)
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		return nil, ErrInternalServer(err)
+	}
+	s.dispatchExtraction(id)
+	return article, nil
+}
+
+// acquireSyncExtraction takes one of the SYNC_EXTRACT_LIMIT slots for an
+// extraction run inside a request. When none is free it fails fast with a
+// 503, or with SYNC_EXTRACT_WAIT waits for one until the request is done.
+func (s *Server) acquireSyncExtraction(ctx context.Context) render.Renderer {
+	select {
+	case s.syncExtractions <- struct{}{}:
+		return nil
+	default:
+	}
+
+	busy := ErrRetryLater(errors.New("too many extractions in progress, try again later"), queueFullRetryAfter)
+	if !s.syncExtractWait {
+		return busy
+	}
+	select {
+	case s.syncExtractions <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return busy
+	}
+}
+
+func (s *Server) releaseSyncExtraction() {
+	<-s.syncExtractions
+}
+
+// runSyncExtraction runs extract in a slot taken with acquireSyncExtraction
+// and gives the slot back however extract returns, even by panicking.
+func (s *Server) runSyncExtraction(ctx context.Context, extract extractFunc) (*types.Article, error) {
+	defer s.releaseSyncExtraction()
+	return extract(ctx)
+}
+
+// startExtractors launches the extraction workers and the poller that
+// re-dispatches pending articles, including any left over from a restart.
+func (s *Server) startExtractors() {
+	if err := s.store.RequeueInterruptedExtractions(); err != nil {
+		log.Printf("error requeueing interrupted extractions: %v", err)
+	}
+	for i := 0; i < s.extractWorkers; i++ {
+		go s.extractionWorker()
+	}
+	go func() {
+		s.dispatchPending()
+		ticker := time.NewTicker(pendingPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.dispatchPending()
+		}
+	}()
+}
+
+// dispatchExtraction hands an article to the workers without blocking. An
+// article that doesn't fit, or arrives in maintenance mode, stays pending
+// and is picked up by a later dispatchPending.
+func (s *Server) dispatchExtraction(id int) {
+	if s.maintenance.Load() || !s.serverFetch {
+		return
+	}
+	select {
+	case s.extractQueue <- id:
+	default:
+	}
+}
+
+// extractQueueFull reports whether the workers already have a full backlog.
+// Pending articles are in the database either way, so this is about
+// throughput, not losing saves.
+func (s *Server) extractQueueFull() bool {
+	return len(s.extractQueue) >= cap(s.extractQueue)
+}
+
+func (s *Server) dispatchPending() {
+	if s.maintenance.Load() {
+		return
+	}
+	ids, err := s.store.GetPendingExtractionIDs()
+	if err != nil {
+		log.Printf("error listing pending extractions: %v", err)
+		return
+	}
+	for _, id := range ids {
+		s.dispatchExtraction(id)
+	}
+}
+
+func (s *Server) extractionWorker() {
+	for id := range s.extractQueue {
+		s.runExtraction(id)
+	}
+}
+
+// runExtraction extracts the metadata of a pending article and stores it.
+// An article can be dispatched more than once; only the worker that claims
+// it does the work.
+func (s *Server) runExtraction(id int) {
+	claimed, err := s.store.ClaimExtraction(id)
+	if err != nil {
+		log.Printf("error claiming extraction of article %d: %v", id, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	defer s.extractionDone.notify(id)
+
+	err = s.extractInto(id)
+	s.extractionStats.record(err)
+	if err == nil {
+		return
+	}
+
+	// transient failures go back to pending for the poller to pick up
+	// again, until the attempts run out and a manual retry is needed
+	var permanent *permanentError
+	retry := !errors.As(err, &permanent) && s.attemptsLeft(id)
+	log.Printf("extraction of article %d failed (retry: %t): %v", id, retry, err)
+	if err := s.store.FailExtraction(id, err.Error(), retry); err != nil {
+		log.Printf("error marking extraction of article %d failed: %v", id, err)
+	}
+}
+
+func (s *Server) attemptsLeft(id int) bool {
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		return false
+	}
+	maxAttempts := s.extractMaxAttempts
+	if article.ExtractionMaxAttempts > 0 {
+		maxAttempts = article.ExtractionMaxAttempts
+	}
+	return article.ExtractionAttempts < maxAttempts
+}
+
+// requestMaxAttempts reads ?retries, the automatic retries a client wants
+// for this article on top of the first attempt. It returns 0, meaning
+// EXTRACT_MAX_ATTEMPTS applies, when the parameter is absent.
+func (s *Server) requestMaxAttempts(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("retries")
+	if raw == "" {
+		return 0, nil
+	}
+	retries, err := strconv.Atoi(raw)
+	if err != nil || retries < 0 {
+		return 0, fmt.Errorf("invalid retries: %s", raw)
+	}
+	if retries > s.extractMaxRetries {
+		return 0, fmt.Errorf("retries must be at most %d", s.extractMaxRetries)
+	}
+	return retries + 1, nil
+}
+
+// permanentError marks an extraction failure that retrying won't fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func (s *Server) extractInto(id int) error {
+	stored, err := s.store.GetArticleByID(id)
+	if err != nil {
+		return err
+	}
+	originalLink := stored.OriginalLink
+	if originalLink == "" {
+		originalLink = stored.Link
+	}
+
+	// every Exa call of this attempt, fallbacks included, shares one budget
+	// of retries on top of its own cap
+	ctx := retry.WithBudget(context.Background(), retry.NewBudget(s.extractRetryBudget))
+	article, err := s.extractor.Extract(ctx, originalLink)
+	if err != nil {
+		return err
+	}
+	if article.Type == types.TypeNotArticle {
+		return &permanentError{failedWith(FailureNotArticle, fmt.Errorf("link supplied is not an article or book"))}
+	}
+
+	// links queued before normalization existed are normalized here
+	article.ID = id
+	article.Link, err = normalizeURL(originalLink)
+	if err != nil {
+		return &permanentError{err}
+	}
+	if article.PaperID == "" {
+		article.PaperID = paperIDFromLink(article.Link)
+	}
+	if article.SiteName == "" {
+		article.SiteName = siteNameFromLink(article.Link)
+	}
+	if !s.storeContent {
+		article.Content = ""
+	}
+	s.sanitizeArticle(article)
+	if article.Type == types.TypePaper {
+		conflict, err := s.paperConflict(article.PaperID, article.Link)
+		if err != nil {
+			return err
+		}
+		if errResp, ok := conflict.(*ErrResponse); ok {
+			return &permanentError{errResp.Err}
+		}
+		if conflict != nil {
+			return &permanentError{fmt.Errorf("paper %s is already saved", article.PaperID)}
+		}
+	}
+
+	err = s.store.CompleteExtraction(article)
+	if errors.Is(err, database.ErrArticleExists) {
+		return &permanentError{err}
+	}
+	if err != nil {
+		return err
+	}
+	s.applySuggestedTags(article)
+	// a first extraction fills a blank record; only re-extractions of an
+	// article that had metadata are worth a history entry
+	if stored.Title != "" {
+		if updated, err := s.store.GetArticleByID(id); err == nil {
+			s.recordHistory(stored, updated, ChangeRefresh)
+		}
+	}
+	if s.archiver != nil && stored.ArchiveURL == "" {
+		go s.archiveArticle(id, article.Link)
+	}
+	if s.embedder != nil {
+		go s.embedArticle(id, embeddingText(article))
+	}
+	return nil
+}
+
+// RetryExtractionHandler re-queues a failed extraction with a fresh attempt
+// budget, so a batch that failed during an outage can be re-driven without
+// re-adding the links.
+func (s *Server) RetryExtractionHandler(w http.ResponseWriter, r *http.Request) {
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	if article.ExtractionStatus != types.ExtractionFailed {
+		render.Render(w, r, ErrConflict(fmt.Errorf("article %d is %s, only failed extractions can be retried", id, article.ExtractionStatus)))
+		return
+	}
+
+	err = s.store.RetryExtraction(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		// it stopped being failed since the check above
+		render.Render(w, r, ErrConflict(fmt.Errorf("article %d is no longer failed", id)))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err = s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.dispatchExtraction(id)
+
+	render.Status(r, http.StatusAccepted)
+	s.renderArticle(w, r, article)
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reading-list-api/internal/types"
+	"testing"
+)
+
+// stubExtractor returns a copy of article, or err, for every link and keeps
+// the links it was asked for.
+type stubExtractor struct {
+	article *types.Article
+	err     error
+	links   []string
+}
+
+func (e *stubExtractor) Extract(ctx context.Context, link string) (*types.Article, error) {
+	e.links = append(e.links, link)
+	if e.err != nil {
+		return nil, e.err
+	}
+	article := *e.article
+	return &article, nil
+}
+
+func stubbedArticle() *types.Article {
+	return &types.Article{
+		Title:     "A post",
+		Author:    "Ada Lovelace",
+		Summary:   "What the post says.",
+		Extractor: ExtractorExaContents,
+	}
+}
+
+func TestExtractionWorkerCompletesArticle(t *testing.T) {
+	extractor := &stubExtractor{article: stubbedArticle()}
+	s, store := newTestServer(t, extractor)
+
+	w := serve(s, http.MethodPost, "/articles", `{"articleLink": "https://example.com/post?utm_source=feed"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /articles = %d: %s", w.Code, w.Body)
+	}
+	var queued ArticleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &queued); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if queued.ExtractionStatus != types.ExtractionPending {
+		t.Errorf("queued article is %s, want pending", queued.ExtractionStatus)
+	}
+
+	// stand in for a worker picking the article off the queue
+	id := <-s.extractQueue
+	if id != queued.ID {
+		t.Fatalf("dispatched article %d, want %d", id, queued.ID)
+	}
+	s.runExtraction(id)
+
+	if len(extractor.links) != 1 || extractor.links[0] != "https://example.com/post?utm_source=feed" {
+		t.Errorf("extracted %q, want the link as submitted", extractor.links)
+	}
+	stored, err := store.GetArticleByID(id)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.ExtractionStatus != types.ExtractionComplete {
+		t.Errorf("article is %s (%s), want complete", stored.ExtractionStatus, stored.ExtractionError)
+	}
+	if stored.Title != "A post" || stored.Author != "Ada Lovelace" || stored.Extractor != ExtractorExaContents {
+		t.Errorf("stored %q by %q from %s, want the extracted metadata", stored.Title, stored.Author, stored.Extractor)
+	}
+	if stored.Link != "https://example.com/post" || stored.SiteName == "" {
+		t.Errorf("stored link %q on site %q, want it normalized with a site name", stored.Link, stored.SiteName)
+	}
+	if attempts, failures := s.extractionStats.snapshot(); attempts != 1 || len(failures) != 0 {
+		t.Errorf("recorded %d attempts with failures %v, want 1 success", attempts, failures)
+	}
+
+	w = serve(s, http.MethodGet, "/articles", "")
+	var page ArticlePageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding listing: %v", err)
+	}
+	if page.TotalArticles != 1 {
+		t.Errorf("listing has %d articles once extracted, want 1", page.TotalArticles)
+	}
+}
+
+func TestExtractionWorkerFailures(t *testing.T) {
+	notArticle := stubbedArticle()
+	notArticle.Type = types.TypeNotArticle
+
+	tests := []struct {
+		name        string
+		extractor   *stubExtractor
+		maxAttempts int
+		want        string
+		reason      string
+	}{
+		{
+			name:        "transient failure is retried",
+			extractor:   &stubExtractor{err: failedWith(FailureFetch, errors.New("exa: no content"))},
+			maxAttempts: 2,
+			want:        types.ExtractionPending,
+			reason:      FailureFetch,
+		},
+		{
+			name:        "last attempt fails",
+			extractor:   &stubExtractor{err: failedWith(FailureFetch, errors.New("exa: no content"))},
+			maxAttempts: 1,
+			want:        types.ExtractionFailed,
+			reason:      FailureFetch,
+		},
+		{
+			name:        "not an article is not retried",
+			extractor:   &stubExtractor{article: notArticle},
+			maxAttempts: 3,
+			want:        types.ExtractionFailed,
+			reason:      FailureNotArticle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newTestServer(t, tt.extractor)
+			article := &types.Article{
+				DateRead:         "2024-05-01",
+				Link:             "https://example.com/post",
+				ExtractionStatus: types.ExtractionPending,
+
+				ExtractionMaxAttempts: tt.maxAttempts,
+			}
+			if err := store.InsertArticle(article); err != nil {
+				t.Fatalf("InsertArticle: %v", err)
+			}
+
+			s.runExtraction(article.ID)
+
+			stored, err := store.GetArticleByID(article.ID)
+			if err != nil {
+				t.Fatalf("GetArticleByID: %v", err)
+			}
+			if stored.ExtractionStatus != tt.want {
+				t.Errorf("article is %s, want %s", stored.ExtractionStatus, tt.want)
+			}
+			if stored.ExtractionError == "" || stored.ExtractionAttempts != 1 {
+				t.Errorf("recorded error %q after %d attempts, want the error after 1", stored.ExtractionError, stored.ExtractionAttempts)
+			}
+			if _, failures := s.extractionStats.snapshot(); failures[tt.reason] != 1 {
+				t.Errorf("recorded failures %v, want one %s", failures, tt.reason)
+			}
+		})
+	}
+}
+
+func TestExtractionWorkerSkipsClaimedArticle(t *testing.T) {
+	extractor := &stubExtractor{article: stubbedArticle()}
+	s, store := newTestServer(t, extractor)
+	article := insertTestArticle(t, store, "done")
+
+	s.runExtraction(article.ID)
+
+	if len(extractor.links) != 0 {
+		t.Errorf("extracted %q for an article that wasn't pending", extractor.links)
+	}
+	stored, err := store.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.ExtractionStatus != types.ExtractionComplete || stored.ExtractionAttempts != 0 {
+		t.Errorf("article is %s after %d attempts, want it left complete", stored.ExtractionStatus, stored.ExtractionAttempts)
+	}
+}
+
+func TestUpsertDuringMaintenanceKeepsArticleListed(t *testing.T) {
+	s, store := newTestServer(t, &stubExtractor{article: stubbedArticle()})
+	article := insertTestArticle(t, store, "post")
+	s.maintenance.Store(true)
+
+	w := serve(s, http.MethodPost, "/articles?upsert=true", `{"articleLink": "`+article.Link+`"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /articles?upsert=true = %d: %s", w.Code, w.Body)
+	}
+	stored, err := store.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.ExtractionStatus != types.ExtractionPending {
+		t.Fatalf("article is %s, want pending re-extraction", stored.ExtractionStatus)
+	}
+
+	w = serve(s, http.MethodGet, "/articles", "")
+	var page ArticlePageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding listing: %v", err)
+	}
+	if page.TotalArticles != 1 || len(page.Articles) != 1 || page.Articles[0].Title != "post" {
+		t.Errorf("listing has %d articles %+v while re-extracting, want the article with its metadata", page.TotalArticles, page.Articles)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"reading-list-api/internal/types"
+)
+
+// MetadataExtractor extracts an article's metadata from its link. A link
+// that isn't an article, paper or book comes back with TypeNotArticle rather
+// than an error.
+type MetadataExtractor interface {
+	Extract(ctx context.Context, link string) (*types.Article, error)
+}
+
+// exaExtractor extracts with Exa's contents summary, falling back to its
+// answer endpoint and the page's meta tags.
+type exaExtractor struct {
+	s *Server
+}
+
+func (e exaExtractor) Extract(ctx context.Context, link string) (*types.Article, error) {
+	return e.s.extractArticleMetadata(ctx, link)
+}
+
+// ExtractorHeader names the extractor that produced an article's metadata.
+const ExtractorHeader = "X-Extractor"
+
+// Extractors recorded on articles. Exa doesn't report which model backs its
+// summaries, so the Exa endpoint used is as specific as it gets. A
+// "+pagemeta" suffix means gaps were filled from the page's meta tags.
+const (
+	ExtractorExaContents = "exa-contents"
+	ExtractorExaAnswer   = "exa-answer"
+	ExtractorHTML        = "html"
+	ExtractorClient      = "client"
+	ExtractorPageMeta    = "pagemeta"
+)
+
+func setExtractorHeader(w http.ResponseWriter, article *types.Article) {
+	if article.Extractor != "" {
+		w.Header().Set(ExtractorHeader, article.Extractor)
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reading-list-api/internal/exa"
+	"reading-list-api/internal/pagemeta"
+	"reading-list-api/internal/tracing"
+	"strings"
+
+	"github.com/go-chi/render"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Fetch strategies, picked per deployment with FETCH_STRATEGY. static
+// downloads the page directly, exa has Exa render it (JavaScript included)
+// with a live crawl, and auto tries static first and falls back to exa when
+// the page comes back empty, as single-page apps tend to.
+const (
+	FetchStatic = "static"
+	FetchExa    = "exa"
+	FetchAuto   = "auto"
+)
+
+// minStaticWords is how much text a static fetch needs before auto trusts it.
+const minStaticWords = 50
+
+// defaultFetchHostIntervalMs is the least time between two fetches to the
+// same host.
+const defaultFetchHostIntervalMs = 1000
+
+func fetchStrategy() string {
+	switch strategy := os.Getenv("FETCH_STRATEGY"); strategy {
+	case "", FetchAuto:
+		return FetchAuto
+	case FetchStatic, FetchExa:
+		return strategy
+	default:
+		log.Printf("invalid FETCH_STRATEGY=%q, using %s", strategy, FetchAuto)
+		return FetchAuto
+	}
+}
+
+// errServerFetchDisabled refuses work that would have the server, or Exa on
+// its behalf, fetch a link when SERVER_FETCH=false.
+var errServerFetchDisabled = errors.New("server-side fetching is disabled (SERVER_FETCH=false): send the page html to POST /articles/from-html instead")
+
+// requireServerFetch returns a 400 when SERVER_FETCH=false, for handlers
+// that can only work by fetching links.
+func (s *Server) requireServerFetch() render.Renderer {
+	if !s.serverFetch {
+		return ErrInvalidRequest(errServerFetchDisabled)
+	}
+	return nil
+}
+
+// exaLivecrawl is the livecrawl mode for Exa requests: with the exa
+// strategy every page is rendered fresh, otherwise cached content is fine.
+func (s *Server) exaLivecrawl() string {
+	if s.fetchStrategy == FetchExa {
+		return "always"
+	}
+	return "preferred"
+}
+
+// fetchPage reads the page at link using the configured strategy.
+func (s *Server) fetchPage(ctx context.Context, link string) (*pagemeta.Page, error) {
+	if s.fetchStrategy == FetchExa {
+		return s.fetchPageViaExa(ctx, link)
+	}
+
+	page, err := s.fetchPageStatic(ctx, link)
+	if s.fetchStrategy == FetchStatic {
+		return page, err
+	}
+	if err == nil && !thinPage(page) {
+		return page, nil
+	}
+	if err != nil {
+		log.Printf("static fetch of %s failed, rendering with exa: %v", link, err)
+	}
+	return s.fetchPageViaExa(ctx, link)
+}
+
+func (s *Server) fetchPageStatic(ctx context.Context, link string) (*pagemeta.Page, error) {
+	rawHTML, err := s.fetcher.GetHTML(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return pagemeta.Parse(link, rawHTML)
+}
+
+// thinPage reports whether a statically fetched page looks like an empty
+// app shell rather than the article.
+func thinPage(page *pagemeta.Page) bool {
+	return page.Title == "" || len(strings.Fields(page.Markdown)) < minStaticWords
+}
+
+// fetchPageViaExa has Exa crawl and render the page, building the page from
+// the metadata and text Exa returns.
+func (s *Server) fetchPageViaExa(ctx context.Context, link string) (*pagemeta.Page, error) {
+	const exaLivecrawlTimeout = 20000 // ms
+
+	exaClient, err := newExaClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := tracing.Start(ctx, "exa.contents.fetch", attribute.String("article.link", link))
+	contents, err := exaClient.Contents(ctx, exa.ContentsRequest{
+		URLs:             []string{link},
+		Text:             map[string]any{"maxCharacters": exaMaxTextCharacters},
+		Livecrawl:        "always",
+		LivecrawlTimeout: exaLivecrawlTimeout,
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if err := exaValidateStatuses(link, contents.Statuses); err != nil {
+		return nil, err
+	}
+	if len(contents.Results) == 0 {
+		return nil, fmt.Errorf("exa contents: no results returned")
+	}
+
+	res := contents.Results[0]
+	page := &pagemeta.Page{
+		Title:    strings.TrimSpace(res.Title),
+		Markdown: res.Text,
+	}
+	if res.Author != nil {
+		page.Author = strings.TrimSpace(*res.Author)
+	}
+	if res.PublishedDate != nil && len(*res.PublishedDate) >= len("2006-01-02") {
+		page.DatePublished = (*res.PublishedDate)[:len("2006-01-02")]
+	}
+	return page, nil
+}
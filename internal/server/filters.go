@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// articleFilter reads the list filters from the query string. ?status takes
+// either a reading status or an extraction status; the two sets don't
+// overlap. ?sort and ?order override the server's default order.
+func articleFilter(r *http.Request, defaultOrder database.ArticleOrder) (database.ArticleFilter, error) {
+	filter := database.ArticleFilter{}
+	query := r.URL.Query()
+
+	order, err := articleOrder(r, defaultOrder)
+	if err != nil {
+		return filter, err
+	}
+	filter.Order = order
+
+	if status := query.Get("status"); status != "" {
+		switch {
+		case types.ValidStatus(status):
+			filter.Status = status
+		case types.ValidExtractionStatus(status):
+			filter.ExtractionStatus = status
+		default:
+			return filter, fmt.Errorf("invalid status %q, must be one of unread, reading, read, pending, processing, complete, failed", status)
+		}
+	}
+
+	if snapshot := query.Get("snapshot"); snapshot != "" {
+		maxID, err := strconv.Atoi(snapshot)
+		if err != nil || maxID < 1 {
+			return filter, fmt.Errorf("invalid snapshot token: %s", snapshot)
+		}
+		filter.MaxID = maxID
+	}
+
+	filter.Site = strings.ToLower(strings.TrimSpace(query.Get("site")))
+	filter.Author = strings.TrimSpace(query.Get("author"))
+
+	for _, tag := range query["tag"] {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(filter.Tags, tag) {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	if inProgress := query.Get("inProgress"); inProgress != "" {
+		v, err := strconv.ParseBool(inProgress)
+		if err != nil {
+			return filter, fmt.Errorf("invalid inProgress: %s", inProgress)
+		}
+		filter.InProgress = v
+	}
+
+	if raw := query.Get("type"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			t, err := strconv.Atoi(strings.TrimSpace(part))
+			if _, ok := types.TypeLabels[t]; err != nil || !ok {
+				return filter, fmt.Errorf("invalid type %q, must be 0 (article), 1 (paper) or 2 (book)", part)
+			}
+			if !slices.Contains(filter.Types, t) {
+				filter.Types = append(filter.Types, t)
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// articleOrder applies ?sort and ?order on top of the default order. Either
+// can be given alone: ?order=asc flips the default sort.
+func articleOrder(r *http.Request, defaultOrder database.ArticleOrder) (database.ArticleOrder, error) {
+	query := r.URL.Query()
+	order, err := database.ParseArticleOrder(query.Get("sort"), query.Get("order"))
+	if err != nil {
+		return order, err
+	}
+	if order.Sort == "" {
+		order.Sort = defaultOrder.Sort
+	}
+	if query.Get("order") == "" {
+		order.Ascending = defaultOrder.Ascending
+	}
+	return order, nil
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reading-list-api/internal/types"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	GoalPeriodMonth = "month"
+	GoalPeriodYear  = "year"
+)
+
+type GoalRequest struct {
+	Type   *int   `json:"type"`
+	Period string `json:"period"`
+	Target *int   `json:"target"`
+}
+
+func (a *GoalRequest) Bind(r *http.Request) error {
+	if a.Type == nil || a.Target == nil {
+		return errors.New("type and target are required")
+	}
+	if _, ok := types.TypeLabels[*a.Type]; !ok {
+		return fmt.Errorf("invalid type %d, must be 0 (article), 1 (paper) or 2 (book)", *a.Type)
+	}
+	if *a.Target < 0 {
+		return errors.New("target must not be negative")
+	}
+	switch a.Period {
+	case "":
+		a.Period = GoalPeriodMonth
+	case GoalPeriodMonth, GoalPeriodYear:
+	default:
+		return fmt.Errorf("invalid period %q, must be month or year", a.Period)
+	}
+	return nil
+}
+
+type GoalProgress struct {
+	Type          int    `json:"type"`
+	Label         string `json:"label"`
+	Period        string `json:"period"`
+	PeriodStart   string `json:"periodStart"`
+	PeriodEnd     string `json:"periodEnd"`
+	Target        int    `json:"target"`
+	Completed     int    `json:"completed"`
+	DaysRemaining int    `json:"daysRemaining"`
+	Met           bool   `json:"met"`
+}
+
+type GoalProgressResponse struct {
+	Goals []GoalProgress `json:"goals"`
+}
+
+func (rd *GoalProgressResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// periodBounds returns the UTC start of the period containing now and the
+// start of the next one.
+func periodBounds(period string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	if period == GoalPeriodYear {
+		start := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (s *Server) GetGoalsHandler(w http.ResponseWriter, r *http.Request) {
+	goals, err := s.db.GetGoals()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	render.Respond(w, r, goals)
+}
+
+// SetGoalHandler sets the target for a type and period, replacing any
+// earlier one. A target of 0 removes the goal.
+func (s *Server) SetGoalHandler(w http.ResponseWriter, r *http.Request) {
+	data := &GoalRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	goal := types.Goal{Type: *data.Type, Period: data.Period, Target: *data.Target}
+	if err := s.db.SetGoal(goal); err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.GetGoalsHandler(w, r)
+}
+
+// GetGoalProgressHandler reports, for each goal, how many articles of its
+// type were finished in the current period and how many days are left.
+func (s *Server) GetGoalProgressHandler(w http.ResponseWriter, r *http.Request) {
+	goals, err := s.db.GetGoals()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	now := time.Now().UTC()
+	resp := &GoalProgressResponse{Goals: make([]GoalProgress, 0, len(goals))}
+	for _, goal := range goals {
+		start, end := periodBounds(goal.Period, now)
+		completed, err := s.db.CountCompleted(goal.Type, start.Format(time.RFC3339), end.Format(time.RFC3339))
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		resp.Goals = append(resp.Goals, GoalProgress{
+			Type:          goal.Type,
+			Label:         types.TypeLabels[goal.Type],
+			Period:        goal.Period,
+			PeriodStart:   start.Format("2006-01-02"),
+			PeriodEnd:     end.AddDate(0, 0, -1).Format("2006-01-02"),
+			Target:        goal.Target,
+			Completed:     completed,
+			DaysRemaining: int(math.Ceil(end.Sub(now).Hours() / 24)),
+			Met:           completed >= goal.Target,
+		})
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// Sources of recorded article changes.
+const (
+	ChangeStatus      = "status"
+	ChangeProgress    = "progress"
+	ChangeImage       = "image"
+	ChangePin         = "pin"
+	ChangeRefresh     = "refresh"
+	ChangeResummarize = "resummarize"
+	ChangeNormalize   = "normalize"
+	ChangeRevert      = "revert"
+	ChangeEdit        = "edit"
+)
+
+// recordHistory stores the fields an edit changed, given the article as it
+// was before and after it. Failures are logged rather than failing the edit.
+func (s *Server) recordHistory(before *types.Article, after *types.Article, source string) {
+	s.recordChange(before, after, &types.ArticleChange{Source: source})
+}
+
+func (s *Server) recordChange(before *types.Article, after *types.Article, change *types.ArticleChange) {
+	if before == nil || after == nil {
+		return
+	}
+	change.ArticleID = after.ID
+	change.Fields = diffHistory(before, after)
+	if err := s.store.RecordChange(change); err != nil {
+		log.Printf("error recording %s change of article %d: %v", change.Source, after.ID, err)
+	}
+}
+
+func diffHistory(before *types.Article, after *types.Article) []types.FieldChange {
+	old := database.HistoryValues(before)
+	changed := make([]types.FieldChange, 0)
+	for field, value := range database.HistoryValues(after) {
+		if old[field] != value {
+			changed = append(changed, types.FieldChange{Field: field, OldValue: old[field], NewValue: value})
+		}
+	}
+	slices.SortFunc(changed, func(a, b types.FieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return changed
+}
+
+type ArticleHistoryResponse struct {
+	Changes []types.ArticleChange `json:"changes"`
+}
+
+func (rd *ArticleHistoryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetArticleHistoryHandler lists the recorded edits of an article, newest
+// first, each with the old and new value of every field it changed.
+func (s *Server) GetArticleHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	_, err = s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	changes, err := s.store.GetArticleHistory(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = render.Render(w, r, &ArticleHistoryResponse{Changes: changes})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+type RevertRequest struct {
+	ChangeID int `json:"changeId"`
+}
+
+func (a *RevertRequest) Bind(r *http.Request) error {
+	if a.ChangeID < 1 {
+		return errors.New("changeId is required")
+	}
+	return nil
+}
+
+// RevertArticleHandler restores an article to how it was before one of its
+// recorded changes, undoing that change and every later one. The revert is
+// recorded as a change itself, so it can be reverted too.
+func (s *Server) RevertArticleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &RevertRequest{}
+	err = render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	changes, err := s.store.GetArticleHistory(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	if !slices.ContainsFunc(changes, func(c types.ArticleChange) bool { return c.ID == data.ChangeID }) {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("article %d has no change %d", id, data.ChangeID)))
+		return
+	}
+
+	// changes are newest first, so walking them in order leaves each field
+	// at its oldest value from the reverted change on
+	restore := make(map[string]string)
+	for _, change := range changes {
+		if change.ID < data.ChangeID {
+			break
+		}
+		for _, field := range change.Fields {
+			restore[field.Field] = field.OldValue
+		}
+	}
+
+	err = s.store.SetHistoryFields(id, restore)
+	if errors.Is(err, database.ErrArticleExists) {
+		render.Render(w, r, ErrConflict(fmt.Errorf("can't revert: the old link is now saved as another article")))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordChange(before, article, &types.ArticleChange{Source: ChangeRevert, RevertOf: data.ChangeID})
+
+	s.renderArticle(w, r, article)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reading-list-api/internal/pagemeta"
+	"reading-list-api/internal/types"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// maxHTMLBodyBytes bounds the page HTML a client may upload.
+const maxHTMLBodyBytes = 5 << 20
+
+// ArticleHTMLRequest carries a page the client already fetched and rendered,
+// for pages the server cannot fetch itself.
+type ArticleHTMLRequest struct {
+	Link string `json:"link"`
+	HTML string `json:"html"`
+}
+
+func (a *ArticleHTMLRequest) Bind(r *http.Request) error {
+	if a.Link == "" || a.HTML == "" {
+		return errors.New("missing required link or html fields")
+	}
+	if err := checkLinkInput(a.Link); err != nil {
+		return err
+	}
+	u, err := url.Parse(a.Link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("link must be an absolute http(s) url: %s", a.Link)
+	}
+	return nil
+}
+
+func (s *Server) CreateArticleFromHTML(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxHTMLBodyBytes)
+
+	data := &ArticleHTMLRequest{}
+	err := render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	s.createArticle(w, r, ExtractorHTML, data.Link, func(ctx context.Context) (*types.Article, error) {
+		return s.extractFromHTML(data.Link, data.HTML)
+	})
+}
+
+// extractFromHTML builds an article from the page's meta tags, falling back
+// to the lead sentence of its markdown when there is no description.
+func (s *Server) extractFromHTML(link string, rawHTML string) (*types.Article, error) {
+	const maxSummaryWords = 30
+
+	page, err := pagemeta.Parse(link, rawHTML)
+	if err != nil {
+		return nil, failedWith(FailureParse, err)
+	}
+
+	summary := strings.TrimSpace(page.Description)
+	if summary == "" {
+		summary = pagemeta.LeadSentence(page.Markdown, maxSummaryWords)
+	}
+	article := &types.Article{
+		Title:         strings.TrimSpace(page.Title),
+		Author:        strings.TrimSpace(page.Author),
+		Summary:       summary,
+		DatePublished: page.DatePublished,
+		Type:          page.Type,
+		DateRead:      time.Now().Format("2006-01-02"),
+		Link:          link,
+		PaperID:       paperIDFromPage(page),
+		SiteName:      strings.TrimSpace(page.SiteName),
+		Extractor:     ExtractorHTML,
+		Content:       strings.TrimSpace(page.Markdown),
+		ImagePath:     page.Image,
+	}
+	s.guessAuthor(article, link)
+	s.guessTitle(article, link)
+
+	if missing := s.incompleteFields(article); len(missing) > 0 {
+		return nil, failedWith(FailureIncomplete, fmt.Errorf("html extraction incomplete: missing %s", strings.Join(missing, ", ")))
+	}
+	return article, nil
+}
+
+// fillFromPage fetches the article page and fills any empty title, author,
+// summary, publish date or image from its meta tags. Values already extracted are
+// kept. Failures are logged and leave the article unchanged.
+func (s *Server) fillFromPage(ctx context.Context, article *types.Article) {
+	const maxSummaryWords = 30
+
+	page, err := s.fetchPage(ctx, article.Link)
+	if err != nil {
+		log.Printf("error fetching %s for metadata fallback: %v", article.Link, err)
+		return
+	}
+
+	before := *article
+	if article.Title == "" {
+		article.Title = strings.TrimSpace(page.Title)
+	}
+	if article.Author == "" {
+		article.Author = strings.TrimSpace(page.Author)
+	}
+	if article.Summary == "" {
+		article.Summary = strings.TrimSpace(page.Description)
+		if article.Summary == "" {
+			article.Summary = pagemeta.LeadSentence(page.Markdown, maxSummaryWords)
+		}
+	}
+	if article.DatePublished == "" {
+		article.DatePublished = page.DatePublished
+	}
+	if article.PaperID == "" {
+		article.PaperID = paperIDFromPage(page)
+	}
+	if article.SiteName == "" {
+		article.SiteName = strings.TrimSpace(page.SiteName)
+	}
+	if article.ImagePath == "" {
+		article.ImagePath = page.Image
+	}
+	if before.Title != article.Title || before.Author != article.Author || before.Summary != article.Summary ||
+		before.DatePublished != article.DatePublished || before.PaperID != article.PaperID ||
+		before.ImagePath != article.ImagePath {
+		article.Extractor += "+" + ExtractorPageMeta
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCreateArticleFromHTMLCountsExtraction(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+	page := `<html><head><title>A post</title>` +
+		`<meta name="author" content="Ada Lovelace">` +
+		`<meta name="description" content="What the post says.">` +
+		`</head><body><p>What the post says, at length.</p></body></html>`
+	body, _ := json.Marshal(ArticleHTMLRequest{Link: "https://example.com/post", HTML: page})
+
+	w := serve(s, http.MethodPost, "/articles/from-html", string(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /articles/from-html = %d: %s", w.Code, w.Body)
+	}
+	attempts, failures := s.extractionStats.snapshot()
+	if attempts != 1 || len(failures) != 0 {
+		t.Errorf("recorded %d attempts with failures %v, want 1 success", attempts, failures)
+	}
+}
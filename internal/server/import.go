@@ -0,0 +1,175 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reading-list-api/internal/importer"
+	"reading-list-api/internal/types"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// maxImportBytes caps the size of an uploaded export file.
+const maxImportBytes = 20 << 20 // 20MB
+
+type ImportError struct {
+	// Line is the CSV line of the row, 0 for other formats.
+	Line  int    `json:"line,omitempty"`
+	Link  string `json:"link"`
+	Error string `json:"error"`
+}
+
+type ImportResponse struct {
+	Imported int           `json:"imported"`
+	Skipped  int           `json:"skipped"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+
+	// status defaults to 202, as imported articles are usually pending
+	status int
+}
+
+func (rd *ImportResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if rd.status == 0 {
+		rd.status = http.StatusAccepted
+	}
+	render.Status(r, rd.status)
+	return nil
+}
+
+// ImportBookmarksHandler imports a browser bookmarks.html, tagging each link
+// with the folders it was filed in.
+func (s *Server) ImportBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	s.importExport(w, r, importer.ParseBookmarks)
+}
+
+// ImportPocketHandler imports a Pocket ril_export.html, keeping each link's
+// save date, read state and tags.
+func (s *Server) ImportPocketHandler(w http.ResponseWriter, r *http.Request) {
+	s.importExport(w, r, importer.ParsePocket)
+}
+
+// importExport parses an uploaded export with parse and saves its entries as
+// pending articles for the extraction workers. Links already saved, or
+// repeated within the file, are skipped.
+func (s *Server) importExport(w http.ResponseWriter, r *http.Request, parse func(io.Reader) ([]importer.Entry, error)) {
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	body, err := importBody(w, r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	defer body.Close()
+
+	entries, err := parse(body)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("error parsing export: %v", err)))
+		return
+	}
+
+	resp := &ImportResponse{Errors: make([]ImportError, 0)}
+	seen := make(map[string]bool)
+	articles := make([]*types.Article, 0, len(entries))
+	tags := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		link, err := normalizeURL(entry.Link)
+		if err == nil && !strings.HasPrefix(link, "http") {
+			err = errors.New("not an http(s) link")
+		}
+		if err == nil {
+			err = checkLinkInput(link)
+		}
+		if err != nil {
+			resp.Failed++
+			resp.Errors = append(resp.Errors, ImportError{Link: entry.Link, Error: err.Error()})
+			continue
+		}
+		exists, err := s.store.ArticleExists(link)
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		if exists || seen[link] {
+			resp.Skipped++
+			continue
+		}
+		seen[link] = true
+		articles = append(articles, importedArticle(entry, link))
+		tags = append(tags, entry.Tags)
+	}
+
+	if s.maxArticles > 0 {
+		total, err := s.librarySize()
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+		room := max(s.maxArticles-total, 0)
+		for _, article := range articles[min(room, len(articles)):] {
+			resp.Failed++
+			resp.Errors = append(resp.Errors, ImportError{Link: article.OriginalLink, Error: fmt.Sprintf("library full: limit of %d articles reached", s.maxArticles)})
+		}
+		articles = articles[:min(room, len(articles))]
+	}
+
+	if err := s.store.InsertArticlesTx(r.Context(), articles, tags[:len(articles)]); err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	resp.Imported = len(articles)
+	for _, article := range articles {
+		s.dispatchExtraction(article.ID)
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// importBody returns the export file, sent either as the raw request body
+// or as the "file" field of a multipart form.
+func importBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.Body, nil
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("error reading uploaded file: %v", err)
+	}
+	return file, nil
+}
+
+// importedArticle builds the pending article for an import entry, backdated
+// to when the link was originally saved.
+func importedArticle(entry importer.Entry, link string) *types.Article {
+	added := entry.AddedAt
+	if added.IsZero() {
+		added = time.Now().UTC()
+	}
+	article := &types.Article{
+		Title:            strings.TrimSpace(entry.Title),
+		DateRead:         added.Format("2006-01-02"),
+		Link:             link,
+		OriginalLink:     entry.Link,
+		PaperID:          paperIDFromLink(link),
+		Status:           types.StatusUnread,
+		CreatedAt:        added.Format(time.RFC3339),
+		ExtractionStatus: types.ExtractionPending,
+	}
+	if entry.Read {
+		article.Status = types.StatusRead
+		article.CompletedAt = article.CreatedAt
+	}
+	return article
+}
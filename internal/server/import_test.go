@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"testing"
+)
+
+func TestImportsCountPendingTowardsLimit(t *testing.T) {
+	pending := func(a *types.Article) { a.ExtractionStatus = types.ExtractionPending }
+
+	t.Run("bookmarks", func(t *testing.T) {
+		s, store := newTestServer(t, nil)
+		s.maxArticles = 3
+		insertTestArticle(t, store, "saved")
+		insertTestArticle(t, store, "queued", pending)
+
+		bookmarks := `<DL><DT><A HREF="https://example.org/one">one</A><DT><A HREF="https://example.org/two">two</A></DL>`
+		w := serve(s, http.MethodPost, "/articles/import/bookmarks", bookmarks)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("import = %d: %s", w.Code, w.Body)
+		}
+		var resp ImportResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Imported != 1 || resp.Failed != 1 {
+			t.Errorf("imported %d and failed %d, want 1 of each with one slot left", resp.Imported, resp.Failed)
+		}
+		if total, _ := store.GetArticleCount(database.ArticleFilter{AnyExtraction: true}); total != 3 {
+			t.Errorf("library has %d articles, want the limit of 3", total)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		s, store := newTestServer(t, nil)
+		s.maxArticles = 3
+		insertTestArticle(t, store, "saved")
+		insertTestArticle(t, store, "queued", pending)
+
+		rows := "link\nhttps://example.org/one\nhttps://example.org/two\n"
+		w := serve(s, http.MethodPost, "/articles/import.csv?extract=true", rows)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("import past the limit = %d: %s", w.Code, w.Body)
+		}
+		if total, _ := store.GetArticleCount(database.ArticleFilter{AnyExtraction: true}); total != 2 {
+			t.Errorf("library has %d articles, want the import rejected", total)
+		}
+	})
+}
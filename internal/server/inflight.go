@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"reading-list-api/internal/types"
+	"sync"
+
+	"github.com/go-chi/render"
+)
+
+// inflightSaves runs one save per key at a time. Requests arriving while a
+// save for their key is running wait for it and share its result instead of
+// repeating the work. The zero value is ready to use.
+type inflightSaves struct {
+	mu    sync.Mutex
+	calls map[string]*inflightSave
+}
+
+type inflightSave struct {
+	done    chan struct{}
+	article *types.Article
+	errResp render.Renderer
+	// expired is set when the save failed because its own request ran out
+	// of time, which says nothing about the requests waiting on it
+	expired bool
+}
+
+// do runs save for key, or waits for the one already running, until ctx is
+// done. A waiter is not handed a failure caused by the running save's
+// deadline; it runs the save again itself.
+func (g *inflightSaves) do(ctx context.Context, key string, save func() (*types.Article, render.Renderer)) (*types.Article, render.Renderer) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*inflightSave)
+		}
+		call, ok := g.calls[key]
+		if !ok {
+			call = &inflightSave{done: make(chan struct{})}
+			g.calls[key] = call
+			g.mu.Unlock()
+			return g.run(ctx, key, call, save)
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, errSaveTimedOut(ctx)
+		}
+		if !call.expired {
+			return call.article, call.errResp
+		}
+	}
+}
+
+func (g *inflightSaves) run(ctx context.Context, key string, call *inflightSave, save func() (*types.Article, render.Renderer)) (*types.Article, render.Renderer) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.article, call.errResp = save()
+	call.expired = call.errResp != nil && ctx.Err() != nil
+	return call.article, call.errResp
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/linkcheck"
+	"reading-list-api/internal/types"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type LinkCheckResponse struct {
+	Checked     int                `json:"checked"`
+	Dead        int                `json:"dead"`
+	Unreachable int                `json:"unreachable"`
+	Flagged     []linkcheck.Result `json:"flagged"`
+}
+
+func (rd *LinkCheckResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// linkCheckTimeout bounds one run over the library.
+const linkCheckTimeout = 5 * time.Minute
+
+func (s *Server) CheckLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if errResp := s.requireServerFetch(); errResp != nil {
+		render.Render(w, r, errResp)
+		return
+	}
+
+	// checking a large library can outlast the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(linkCheckTimeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), linkCheckTimeout)
+	defer cancel()
+
+	articles, err := s.store.GetAllArticles(database.ArticleOrder{})
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	resp, err := s.checkLinks(ctx, *articles)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// checkLinks checks the links of articles and records their status.
+func (s *Server) checkLinks(ctx context.Context, articles []types.Article) (*LinkCheckResponse, error) {
+	// per link, including any wait for other links on the same host
+	const linkCheckRequestTimeout = 30 * time.Second
+
+	targets := make([]linkcheck.Target, 0, len(articles))
+	for _, a := range articles {
+		targets = append(targets, linkcheck.Target{ID: a.ID, Link: a.Link})
+	}
+
+	checker := linkcheck.NewChecker(linkcheck.CheckerConfig{
+		Concurrency: envInt("LINK_CHECK_CONCURRENCY", 5),
+		HTTPClient: &http.Client{
+			Timeout:   linkCheckRequestTimeout,
+			Transport: s.hostLimiter.Transport(nil),
+		},
+	})
+	results := checker.CheckAll(ctx, targets)
+
+	checkedAt := time.Now().Format(time.RFC3339)
+	resp := &LinkCheckResponse{Checked: len(results), Flagged: make([]linkcheck.Result, 0)}
+	for _, res := range results {
+		if err := s.db.SetLinkStatus(res.ID, res.Status, checkedAt); err != nil {
+			return nil, err
+		}
+		switch res.Status {
+		case linkcheck.StatusDead:
+			resp.Dead++
+		case linkcheck.StatusUnreachable:
+			resp.Unreachable++
+		default:
+			continue
+		}
+		resp.Flagged = append(resp.Flagged, res)
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"reading-list-api/internal/types"
+	"sync"
+	"time"
+)
+
+// longPollTimeout bounds GET /articles/{id}?wait=true. It stays under the
+// server's write timeout so the current state can still be written.
+const longPollTimeout = 25 * time.Second
+
+// extractionWaiters wakes requests waiting on an article when an extraction
+// attempt on it ends. The zero value is ready to use.
+type extractionWaiters struct {
+	mu      sync.Mutex
+	waiters map[int][]chan struct{}
+}
+
+// wait returns a channel closed at the end of the next extraction attempt
+// on id, and a func to stop waiting.
+func (w *extractionWaiters) wait(id int) (<-chan struct{}, func()) {
+	ch := make(chan struct{})
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = make(map[int][]chan struct{})
+	}
+	w.waiters[id] = append(w.waiters[id], ch)
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		chans := w.waiters[id]
+		for i, c := range chans {
+			if c == ch {
+				w.waiters[id] = append(chans[:i], chans[i+1:]...)
+				break
+			}
+		}
+		if len(w.waiters[id]) == 0 {
+			delete(w.waiters, id)
+		}
+	}
+}
+
+func (w *extractionWaiters) notify(id int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.waiters[id] {
+		close(ch)
+	}
+	delete(w.waiters, id)
+}
+
+func extracting(article *types.Article) bool {
+	return article.ExtractionStatus == types.ExtractionPending || article.ExtractionStatus == types.ExtractionProcessing
+}
+
+// waitForExtraction blocks until the article's extraction completes or
+// fails, the timeout passes or ctx is done, and returns its latest state.
+// Attempts that end back in pending for a retry keep it waiting.
+func (s *Server) waitForExtraction(ctx context.Context, article *types.Article) (*types.Article, error) {
+	timeout := time.NewTimer(longPollTimeout)
+	defer timeout.Stop()
+
+	for extracting(article) {
+		// subscribe before re-reading so an attempt that ends in between
+		// isn't missed
+		done, stop := s.extractionDone.wait(article.ID)
+		latest, err := s.store.GetArticleByID(article.ID)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		article = latest
+		if !extracting(article) {
+			stop()
+			break
+		}
+
+		select {
+		case <-done:
+		case <-timeout.C:
+			stop()
+			return article, nil
+		case <-ctx.Done():
+			stop()
+			return nil, ctx.Err()
+		}
+		stop()
+	}
+	return article, nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+func (m *MaintenanceRequest) Bind(r *http.Request) error {
+	if m.Enabled == nil {
+		return errors.New("enabled is required")
+	}
+	return nil
+}
+
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+	Pending int  `json:"pending"`
+}
+
+func (rd *MaintenanceResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func (s *Server) GetMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	s.renderMaintenance(w, r)
+}
+
+// SetMaintenanceHandler pauses or resumes background extraction. Leaving
+// maintenance mode dispatches the pending articles straight away.
+func (s *Server) SetMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	data := &MaintenanceRequest{}
+	err := render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	s.maintenance.Store(*data.Enabled)
+	log.Printf("maintenance mode set to %t", *data.Enabled)
+	if !*data.Enabled {
+		s.dispatchPending()
+	}
+	s.renderMaintenance(w, r)
+}
+
+func (s *Server) renderMaintenance(w http.ResponseWriter, r *http.Request) {
+	pending, err := s.store.GetPendingExtractionIDs()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	err = render.Render(w, r, &MaintenanceResponse{Enabled: s.maintenance.Load(), Pending: len(pending)})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reading-list-api/internal/types"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultExtractMetricsWindow is how many recent extraction attempts the
+// success rate is computed over.
+const defaultExtractMetricsWindow = 100
+
+// Reasons an extraction attempt failed, as broken down in the metrics.
+const (
+	// FailureFetch: Exa couldn't fetch the page or returned nothing for it
+	FailureFetch = "fetch"
+	// FailureParse: neither Exa's summary nor the answer fallback gave
+	// usable details
+	FailureParse = "parse"
+	// FailureIncomplete: the details lacked fields the type requires
+	FailureIncomplete = "incomplete"
+	// FailureNotArticle: the link isn't an article, paper or book
+	FailureNotArticle = "not_article"
+	// FailureTimeout: the attempt ran out of time
+	FailureTimeout = "timeout"
+	// FailureOther: anything else, such as a database error
+	FailureOther = "other"
+)
+
+var failureReasons = []string{FailureFetch, FailureParse, FailureIncomplete, FailureNotArticle, FailureTimeout, FailureOther}
+
+// extractionFailure tags an extraction error with its reason for the
+// metrics, leaving its message as it was.
+type extractionFailure struct {
+	reason string
+	err    error
+}
+
+func failedWith(reason string, err error) error {
+	return &extractionFailure{reason: reason, err: err}
+}
+
+func (e *extractionFailure) Error() string { return e.err.Error() }
+func (e *extractionFailure) Unwrap() error { return e.err }
+
+func failureReason(err error) string {
+	var failure *extractionFailure
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.As(err, &failure):
+		return failure.reason
+	}
+	return FailureOther
+}
+
+// extractionStats is a ring buffer of the outcomes of the latest extraction
+// attempts: "" for a success, otherwise the failure reason.
+type extractionStats struct {
+	mu       sync.Mutex
+	outcomes []string
+	next     int
+	full     bool
+}
+
+func newExtractionStats(window int) *extractionStats {
+	return &extractionStats{outcomes: make([]string, max(window, 1))}
+}
+
+func (e *extractionStats) record(err error) {
+	outcome := ""
+	if err != nil {
+		outcome = failureReason(err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.outcomes[e.next] = outcome
+	e.next = (e.next + 1) % len(e.outcomes)
+	e.full = e.full || e.next == 0
+}
+
+// snapshot returns the attempts in the window and the failures among them
+// by reason.
+func (e *extractionStats) snapshot() (int, map[string]int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	attempts := e.next
+	if e.full {
+		attempts = len(e.outcomes)
+	}
+	failures := make(map[string]int, len(failureReasons))
+	for _, outcome := range e.outcomes[:attempts] {
+		if outcome != "" {
+			failures[outcome]++
+		}
+	}
+	return attempts, failures
+}
+
+// successRate is the share of attempts that succeeded, NaN without any.
+func successRate(attempts int, failures map[string]int) float64 {
+	if attempts == 0 {
+		return math.NaN()
+	}
+	failed := 0
+	for _, n := range failures {
+		failed += n
+	}
+	return float64(attempts-failed) / float64(attempts)
+}
+
+// health adds the rolling extraction stats to the /health stats.
+func (e *extractionStats) health(stats map[string]string) {
+	attempts, failures := e.snapshot()
+	stats["extraction_attempts"] = strconv.Itoa(attempts)
+	if attempts == 0 {
+		stats["extraction_success_rate"] = "n/a"
+	} else {
+		stats["extraction_success_rate"] = strconv.FormatFloat(successRate(attempts, failures), 'f', 3, 64)
+	}
+	for _, reason := range failureReasons {
+		stats["extraction_failures_"+reason] = strconv.Itoa(failures[reason])
+	}
+}
+
+// MetricsHandler serves the rolling extraction stats in the Prometheus text
+// format.
+func (s *Server) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	attempts, failures := s.extractionStats.snapshot()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP reading_list_extraction_success_rate Share of the last %d extraction attempts that succeeded.\n", len(s.extractionStats.outcomes))
+	b.WriteString("# TYPE reading_list_extraction_success_rate gauge\n")
+	fmt.Fprintf(&b, "reading_list_extraction_success_rate %s\n", strconv.FormatFloat(successRate(attempts, failures), 'g', -1, 64))
+	b.WriteString("# HELP reading_list_extraction_window_attempts Extraction attempts in the success rate window.\n")
+	b.WriteString("# TYPE reading_list_extraction_window_attempts gauge\n")
+	fmt.Fprintf(&b, "reading_list_extraction_window_attempts %d\n", attempts)
+	b.WriteString("# HELP reading_list_extraction_window_failures Failed extraction attempts in the window by reason.\n")
+	b.WriteString("# TYPE reading_list_extraction_window_failures gauge\n")
+	reasons := slices.Clone(failureReasons)
+	slices.Sort(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "reading_list_extraction_window_failures{reason=%q} %d\n", reason, failures[reason])
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// extractionOutcome is the error a synchronous extraction counts as: the
+// deadline if the save ran out of time, a link that isn't an article, or
+// whatever the extraction returned.
+func extractionOutcome(ctx context.Context, article *types.Article, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err == nil && article.Type == types.TypeNotArticle {
+		return failedWith(FailureNotArticle, errors.New("link supplied is not an article or book"))
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+
+	"github.com/go-chi/render"
+)
+
+type ArticleLinkRequest struct {
+	Link  string `json:"link"`
+	Label string `json:"label"`
+}
+
+func (a *ArticleLinkRequest) Bind(r *http.Request) error {
+	if a.Link == "" {
+		return errors.New("missing required link field")
+	}
+	if err := checkLinkInput(a.Link); err != nil {
+		return err
+	}
+	u, err := url.Parse(a.Link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("link must be an absolute http(s) url: %s", a.Link)
+	}
+	return nil
+}
+
+type ArticleLinksResponse struct {
+	Links []types.ArticleLink `json:"links"`
+}
+
+func (rd *ArticleLinksResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type ArticleLinkResponse struct {
+	*types.ArticleLink
+}
+
+func (rd *ArticleLinkResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// AddArticleLinkHandler records a mirror of an article, such as an archive
+// copy or a syndicated version. The article keeps its primary link; saving
+// any of its links again is a conflict.
+func (s *Server) AddArticleLinkHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &ArticleLinkRequest{}
+	err = render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	link, err := normalizeURL(data.Link)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	articleLink := &types.ArticleLink{ArticleID: id, Link: link, Label: data.Label}
+	err = s.db.AddArticleLink(articleLink)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if errors.Is(err, database.ErrArticleExists) {
+		render.Render(w, r, ErrConflict(fmt.Errorf("link is already saved: %s", link)))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	err = render.Render(w, r, &ArticleLinkResponse{ArticleLink: articleLink})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// GetArticleLinksHandler lists the alternate links of an article, oldest
+// first. The primary link is the article's own link field.
+func (s *Server) GetArticleLinksHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	_, err = s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	links, err := s.db.GetArticleLinks(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = render.Render(w, r, &ArticleLinksResponse{Links: links})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
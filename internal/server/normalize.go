@@ -0,0 +1,173 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// defaultMaxLinkLength matches the URL length most browsers and servers
+// handle.
+const defaultMaxLinkLength = 2048
+
+// maxLinkLength is read once, as request binding has no Server to ask.
+var maxLinkLength = envInt("MAX_LINK_LENGTH", defaultMaxLinkLength)
+
+// checkLinkInput rejects links that can't be an article worth storing:
+// data: URIs and links longer than MAX_LINK_LENGTH.
+func checkLinkInput(link string) error {
+	link = strings.TrimSpace(link)
+	if len(link) >= len("data:") && strings.EqualFold(link[:len("data:")], "data:") {
+		return errors.New("data: URIs can't be saved, send the page's link instead")
+	}
+	if maxLinkLength > 0 && len(link) > maxLinkLength {
+		return fmt.Errorf("link is %d characters long, more than the %d allowed", len(link), maxLinkLength)
+	}
+	return nil
+}
+
+// absoluteHTTPURL reports whether link parses as an http(s) URL with a host.
+func absoluteHTTPURL(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return false
+	}
+	return (strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")) && u.Host != ""
+}
+
+// siteNameFromLink is the link's host without www., the site name of pages
+// that don't give one.
+func siteNameFromLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// trackingParams are stripped from every link. A trailing "*" matches any
+// parameter with that prefix.
+var trackingParams = []string{"utm_*", "fbclid", "gclid", "ref"}
+
+// queryRule controls which query parameters survive normalization for links
+// on a domain and its subdomains. When keep is set, only those parameters
+// are kept; otherwise the strip parameters are removed on top of the
+// tracking ones. A strip list of "*" removes the whole query.
+type queryRule struct {
+	keep  []string
+	strip []string
+}
+
+// defaultQueryRules can be extended or overridden with URL_PARAM_RULES.
+var defaultQueryRules = map[string]queryRule{
+	"youtube.com": {keep: []string{"v", "t", "list"}},
+	"medium.com":  {strip: []string{"*"}},
+}
+
+var queryRules = loadQueryRules(os.Getenv("URL_PARAM_RULES"))
+
+// loadQueryRules merges rules of the form
+// "youtube.com=keep:v,t;medium.com=strip:*" into the defaults.
+func loadQueryRules(raw string) map[string]queryRule {
+	rules := make(map[string]queryRule, len(defaultQueryRules))
+	for domain, rule := range defaultQueryRules {
+		rules[domain] = rule
+	}
+
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		domain, spec, ok := strings.Cut(entry, "=")
+		mode, params, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 || (mode != "keep" && mode != "strip") {
+			log.Printf("ignoring malformed URL_PARAM_RULES entry %q", entry)
+			continue
+		}
+
+		var list []string
+		for _, p := range strings.Split(params, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				list = append(list, p)
+			}
+		}
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if mode == "keep" {
+			rules[domain] = queryRule{keep: list}
+		} else {
+			rules[domain] = queryRule{strip: list}
+		}
+	}
+	return rules
+}
+
+// normalizeURL canonicalises an article link so the same page saved with
+// different tracking parameters, host casing, fragment or trailing slash
+// dedups to a single row. Only http(s) links are accepted.
+func normalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("invalid link: %v", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid link %q: must be an absolute http(s) URL", raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment, u.RawFragment = "", ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	rule := ruleForHost(u.Hostname())
+	query := u.Query()
+	for key := range query {
+		if dropParam(key, rule) {
+			query.Del(key)
+		}
+	}
+	// Encode sorts the keys, which also makes the order canonical
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+func ruleForHost(host string) queryRule {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for host != "" {
+		if rule, ok := queryRules[host]; ok {
+			return rule
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found {
+			break
+		}
+		host = parent
+	}
+	return queryRule{}
+}
+
+func dropParam(key string, rule queryRule) bool {
+	if rule.keep != nil {
+		return !slices.Contains(rule.keep, key)
+	}
+	return matchParam(key, trackingParams) || matchParam(key, rule.strip)
+}
+
+func matchParam(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"reading-list-api/internal/database"
+
+	"github.com/go-chi/render"
+)
+
+type NormalizedLink struct {
+	ID   int    `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DuplicateLink is an article whose normalized link is already taken by
+// another article; the two are left for the user to merge.
+type DuplicateLink struct {
+	ID          int    `json:"id"`
+	Link        string `json:"link"`
+	Normalized  string `json:"normalized"`
+	DuplicateOf int    `json:"duplicateOf"`
+}
+
+type InvalidLink struct {
+	ID    int    `json:"id"`
+	Link  string `json:"link"`
+	Error string `json:"error"`
+}
+
+type NormalizeLinksResponse struct {
+	Checked    int              `json:"checked"`
+	DryRun     bool             `json:"dryRun"`
+	Updated    []NormalizedLink `json:"updated"`
+	Duplicates []DuplicateLink  `json:"duplicates"`
+	Invalid    []InvalidLink    `json:"invalid"`
+}
+
+func (rd *NormalizeLinksResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// NormalizeLinksHandler rewrites stored links saved before normalization to
+// their normalized form. Links that would collide with another article are
+// reported instead of changed. With ?dryRun=true nothing is written.
+func (s *Server) NormalizeLinksHandler(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	articles, err := s.store.GetAllArticles(database.ArticleOrder{})
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	resp := &NormalizeLinksResponse{
+		Checked:    len(*articles),
+		DryRun:     dryRun,
+		Updated:    make([]NormalizedLink, 0),
+		Duplicates: make([]DuplicateLink, 0),
+		Invalid:    make([]InvalidLink, 0),
+	}
+	// claimed tracks normalized links taken during this run, so a dry run
+	// reports the same duplicates a real one would hit
+	claimed := make(map[string]int)
+	for _, article := range *articles {
+		claimed[article.Link] = article.ID
+	}
+
+	for _, article := range *articles {
+		normalized, err := normalizeURL(article.Link)
+		if err != nil {
+			resp.Invalid = append(resp.Invalid, InvalidLink{ID: article.ID, Link: article.Link, Error: err.Error()})
+			continue
+		}
+		if normalized == article.Link {
+			continue
+		}
+
+		if owner, ok := claimed[normalized]; ok {
+			resp.Duplicates = append(resp.Duplicates, DuplicateLink{ID: article.ID, Link: article.Link, Normalized: normalized, DuplicateOf: owner})
+			continue
+		}
+		if existing, err := s.store.GetArticleByLink(normalized); err == nil {
+			// taken by an article outside the listing, e.g. one still pending
+			resp.Duplicates = append(resp.Duplicates, DuplicateLink{ID: article.ID, Link: article.Link, Normalized: normalized, DuplicateOf: existing.ID})
+			continue
+		} else if !errors.Is(err, database.ErrArticleNotFound) {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+
+		if !dryRun {
+			if err := s.db.SetLink(article.ID, normalized); err != nil {
+				render.Render(w, r, ErrInternalServer(err))
+				return
+			}
+			updated := article
+			updated.Link = normalized
+			s.recordHistory(&article, &updated, ChangeNormalize)
+		}
+		delete(claimed, article.Link)
+		claimed[normalized] = article.ID
+		resp.Updated = append(resp.Updated, NormalizedLink{ID: article.ID, From: article.Link, To: normalized})
+	}
+
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
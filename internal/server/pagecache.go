@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPageCacheTTLMs = 10000
+
+	// maxPageCacheEntries bounds the cache; when full it is emptied, as only
+	// a handful of hot pages are worth keeping anyway.
+	maxPageCacheEntries = 256
+)
+
+// cachedPage is one GET /articles response as computed from the database.
+type cachedPage struct {
+	version  uint64
+	expires  time.Time
+	total    int
+	snapshot string
+	articles *[]types.Article
+}
+
+// pageCache keeps recent article pages for a short TTL. An entry is only
+// served while the database's data version matches the one it was built
+// at, so any write invalidates every cached page. A zero TTL disables it.
+type pageCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedPage
+}
+
+// pageCacheKey names the page of a listing. The filter is JSON encoded so
+// the key keeps its fields apart whatever they contain: tags with spaces or
+// an author that reads like another filter can't share a key.
+func pageCacheKey(page int, pageSize int, filter database.ArticleFilter) string {
+	// a filter is plain strings, numbers and slices, which always encode
+	key, _ := json.Marshal(struct {
+		Page     int
+		PageSize int
+		Filter   database.ArticleFilter
+	}{page, pageSize, filter})
+	return string(key)
+}
+
+func newPageCache(ttl time.Duration) *pageCache {
+	return &pageCache{
+		ttl:     ttl,
+		entries: make(map[string]cachedPage),
+	}
+}
+
+func (c *pageCache) get(key string, version uint64) (cachedPage, bool) {
+	if c.ttl <= 0 {
+		return cachedPage{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	page, ok := c.entries[key]
+	if !ok {
+		return cachedPage{}, false
+	}
+	if page.version != version || time.Now().After(page.expires) {
+		delete(c.entries, key)
+		return cachedPage{}, false
+	}
+	return page, true
+}
+
+// put stores page under key. version must be read before the page was
+// queried, so a write landing in between leaves the entry already stale.
+func (c *pageCache) put(key string, version uint64, page cachedPage) {
+	if c.ttl <= 0 {
+		return
+	}
+	page.version = version
+	page.expires = time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxPageCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[key] = page
+}
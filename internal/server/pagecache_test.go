@@ -0,0 +1,37 @@
+package server
+
+import (
+	"reading-list-api/internal/database"
+	"testing"
+)
+
+func TestPageCacheKey(t *testing.T) {
+	distinct := []database.ArticleFilter{
+		{},
+		{Tags: []string{"a b"}},
+		{Tags: []string{"a", "b"}},
+		{Author: "x"},
+		// an author spelling out the rest of another filter
+		{Author: "x Tags:[a b]"},
+		{Author: "x", Tags: []string{"a", "b"}},
+		{Site: "example.com"},
+		{Types: []int{1, 2}},
+		{Types: []int{12}},
+	}
+	keys := make(map[string]int)
+	for i, filter := range distinct {
+		key := pageCacheKey(1, 10, filter)
+		if j, ok := keys[key]; ok {
+			t.Errorf("filters %+v and %+v share the key %s", distinct[j], filter, key)
+		}
+		keys[key] = i
+	}
+
+	filter := database.ArticleFilter{Tags: []string{"go"}, Order: database.ArticleOrder{Sort: "title"}}
+	if pageCacheKey(2, 10, filter) != pageCacheKey(2, 10, filter) {
+		t.Error("the same page of the same filter gets different keys")
+	}
+	if pageCacheKey(1, 10, filter) == pageCacheKey(2, 10, filter) {
+		t.Error("different pages share a key")
+	}
+}
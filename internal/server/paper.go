@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/url"
+	"reading-list-api/internal/pagemeta"
+	"regexp"
+	"strings"
+)
+
+var (
+	doiPattern   = regexp.MustCompile(`(?i)\b(10\.\d{4,9}/[^\s?#"'<>]+)`)
+	arxivPattern = regexp.MustCompile(`(?i)arxiv\.org/(?:abs|pdf|html)/(\d{4}\.\d{4,5}|[a-z\-]+(?:\.[a-z]{2})?/\d{7})`)
+	arxivVersion = regexp.MustCompile(`v\d+$`)
+)
+
+// paperIDFromLink derives a stable paper identifier from arXiv and DOI links,
+// so the same paper saved from different URLs dedups to one row. It returns
+// "" for links that don't name a paper.
+func paperIDFromLink(link string) string {
+	if m := arxivPattern.FindStringSubmatch(link); m != nil {
+		return arxivID(strings.TrimSuffix(m[1], ".pdf"))
+	}
+	if u, err := url.Parse(link); err == nil {
+		path, _ := url.PathUnescape(u.EscapedPath())
+		if m := doiPattern.FindStringSubmatch(path); m != nil {
+			return doiID(m[1])
+		}
+	}
+	return ""
+}
+
+// paperIDFromPage reads the identifier from a page's citation meta tags.
+func paperIDFromPage(page *pagemeta.Page) string {
+	if page.DOI != "" {
+		if m := doiPattern.FindStringSubmatch(page.DOI); m != nil {
+			return doiID(m[1])
+		}
+	}
+	if page.ArxivID != "" {
+		return arxivID(page.ArxivID)
+	}
+	return ""
+}
+
+func doiID(doi string) string {
+	// DOIs are case-insensitive
+	return "doi:" + strings.ToLower(strings.TrimRight(doi, ".,;"))
+}
+
+func arxivID(id string) string {
+	// every version of a preprint is the same paper
+	return "arxiv:" + arxivVersion.ReplaceAllString(strings.ToLower(id), "")
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// MergePatchContentType is the media type of a JSON Merge Patch (RFC 7386).
+const MergePatchContentType = "application/merge-patch+json"
+
+// maxPatchBytes caps the body of an article patch.
+const maxPatchBytes = 64 << 10 // 64KB
+
+// maxRating is the highest rating an article can be given; the lowest is 1.
+const maxRating = 5
+
+// parseArticlePatch reads a merge patch of an article into the columns to
+// set. A key set to null clears that field: strings become empty, type goes
+// back to 0, an article, and rating to unrated. Absent keys are left alone.
+func parseArticlePatch(body []byte) (map[string]any, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, errors.New("a merge patch of an article must be a JSON object")
+	}
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any, len(patch))
+	for field, raw := range patch {
+		if _, ok := database.PatchColumns[field]; !ok {
+			editable := make([]string, 0, len(database.PatchColumns))
+			for name := range database.PatchColumns {
+				editable = append(editable, name)
+			}
+			slices.Sort(editable)
+			return nil, fmt.Errorf("field %q can't be edited, only %s", field, strings.Join(editable, ", "))
+		}
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+		if field == "type" {
+			t := 0
+			if !isNull {
+				if err := json.Unmarshal(raw, &t); err != nil {
+					return nil, errors.New("type must be an integer or null")
+				}
+			}
+			if _, ok := types.TypeLabels[t]; !ok {
+				return nil, fmt.Errorf("invalid type %d, must be 0 (article), 1 (paper) or 2 (book)", t)
+			}
+			fields[field] = t
+			continue
+		}
+
+		if field == "rating" {
+			if isNull {
+				fields[field] = nil
+				continue
+			}
+			var rating int
+			if err := json.Unmarshal(raw, &rating); err != nil {
+				return nil, errors.New("rating must be an integer or null")
+			}
+			if rating < 1 || rating > maxRating {
+				return nil, fmt.Errorf("invalid rating %d, must be between 1 and %d", rating, maxRating)
+			}
+			fields[field] = rating
+			continue
+		}
+
+		value := ""
+		if !isNull {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("%s must be a string or null", field)
+			}
+			value = strings.TrimSpace(value)
+		}
+		if field == "title" && value == "" {
+			return nil, errors.New("title can't be cleared")
+		}
+		fields[field] = value
+	}
+	return fields, nil
+}
+
+// PatchArticleHandler edits an article's metadata with a JSON Merge Patch:
+// keys set to null clear the field and absent keys leave it unchanged, so a
+// client can clear a wrongly guessed author without resending the rest.
+func (s *Server) PatchArticleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	// plain JSON is read as a merge patch too, as most clients send it
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != MergePatchContentType && mediaType != "application/json" {
+		render.Render(w, r, ErrUnsupportedMediaType(fmt.Errorf("Content-Type must be %s or application/json", MergePatchContentType)))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	fields, err := parseArticlePatch(body)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = s.store.PatchArticle(id, fields)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordHistory(before, article, ChangeEdit)
+
+	s.renderArticle(w, r, article)
+}
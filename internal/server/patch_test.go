@@ -0,0 +1,138 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseArticlePatchRating(t *testing.T) {
+	for rating := 1; rating <= maxRating; rating++ {
+		fields, err := parseArticlePatch([]byte(`{"rating": ` + strconv.Itoa(rating) + `}`))
+		if err != nil {
+			t.Errorf("rating %d: %v", rating, err)
+			continue
+		}
+		if want := map[string]any{"rating": rating}; !reflect.DeepEqual(fields, want) {
+			t.Errorf("rating %d parsed as %v, want %v", rating, fields, want)
+		}
+	}
+
+	fields, err := parseArticlePatch([]byte(`{"rating": null}`))
+	if err != nil {
+		t.Fatalf("null rating: %v", err)
+	}
+	if want := map[string]any{"rating": nil}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("null rating parsed as %v, want %v", fields, want)
+	}
+
+	for _, body := range []string{
+		`{"rating": 0}`,
+		`{"rating": 6}`,
+		`{"rating": -1}`,
+		`{"rating": 4.5}`,
+		`{"rating": "4"}`,
+		`{"rating": true}`,
+	} {
+		if fields, err := parseArticlePatch([]byte(body)); err == nil {
+			t.Errorf("%s parsed as %v, want an error", body, fields)
+		}
+	}
+}
+
+func TestParseArticlePatch(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    map[string]any
+		wantErr bool
+	}{
+		{body: `{}`, want: map[string]any{}},
+		{body: `{"author": null, "notes": "  worth a reread "}`, want: map[string]any{"author": "", "notes": "worth a reread"}},
+		{body: `{"type": 2}`, want: map[string]any{"type": 2}},
+		{body: `{"type": null}`, want: map[string]any{"type": 0}},
+		{body: `{"type": 7}`, wantErr: true},
+		{body: `{"title": null}`, wantErr: true},
+		{body: `{"link": "https://example.com"}`, wantErr: true},
+		{body: `{"notes": 3}`, wantErr: true},
+		{body: `[]`, wantErr: true},
+		{body: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		fields, err := parseArticlePatch([]byte(tt.body))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s parsed as %v, want an error", tt.body, fields)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.body, err)
+			continue
+		}
+		if !reflect.DeepEqual(fields, tt.want) {
+			t.Errorf("%s parsed as %v, want %v", tt.body, fields, tt.want)
+		}
+	}
+}
+
+func TestPatchArticleHandler(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	article := insertTestArticle(t, store, "post")
+
+	req := httptest.NewRequest(http.MethodPatch, "/articles/"+strconv.Itoa(article.ID), strings.NewReader(`{"rating": 4, "notes": "worth a reread"}`))
+	req.Header.Set("Content-Type", MergePatchContentType)
+	w := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH = %d: %s", w.Code, w.Body)
+	}
+
+	stored, err := store.GetArticleByID(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleByID: %v", err)
+	}
+	if stored.Rating == nil || *stored.Rating != 4 || stored.Notes != "worth a reread" {
+		t.Errorf("stored rating %v and notes %q, want 4 and the notes", stored.Rating, stored.Notes)
+	}
+	history, err := store.GetArticleHistory(article.ID)
+	if err != nil {
+		t.Fatalf("GetArticleHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].Source != ChangeEdit {
+		t.Errorf("history %+v, want the edit recorded", history)
+	}
+}
+
+func TestPatchArticleHandlerContentType(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	article := insertTestArticle(t, store, "post")
+	target := "/articles/" + strconv.Itoa(article.ID)
+
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{MergePatchContentType, http.StatusOK},
+		{"application/json", http.StatusOK},
+		{"application/json; charset=utf-8", http.StatusOK},
+		{"text/plain", http.StatusUnsupportedMediaType},
+		{"", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"notes": "from `+tt.contentType+`"}`))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("PATCH with Content-Type %q = %d, want %d: %s", tt.contentType, w.Code, tt.want, w.Body)
+		}
+		if w.Code == http.StatusUnsupportedMediaType && !strings.Contains(w.Body.String(), MergePatchContentType) {
+			t.Errorf("415 body %s doesn't name the expected type", w.Body)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"reading-list-api/internal/types"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// readwiseColumns is the layout of Readwise's CSV highlight import.
+var readwiseColumns = []string{"Highlight", "Title", "Author", "URL", "Note", "Location", "Date"}
+
+// ExportReadwiseHandler writes articles as a CSV Readwise can import. Every
+// Readwise entry needs a highlight, and the library has no highlights yet,
+// so each article is exported as one highlight of its summary. Its notes go
+// in the note, followed by its tags as Readwise inline tags. ?status limits
+// the export to one reading status, e.g. read.
+func (s *Server) ExportReadwiseHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && !types.ValidStatus(status) {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid status %q, must be one of unread, reading, read", status)))
+		return
+	}
+
+	articles, err := s.store.GetAllArticles(s.defaultOrder)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	tagsByArticle, err := s.store.GetTagsByArticle()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="readwise.csv"`)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(readwiseColumns); err != nil {
+		log.Printf("error writing readwise export: %v", err)
+		return
+	}
+	for _, article := range *articles {
+		if status != "" && article.Status != status {
+			continue
+		}
+		highlight := article.Summary
+		if highlight == "" {
+			highlight = article.Title
+		}
+		tags := tagsByArticle[article.ID]
+		err = cw.Write([]string{
+			highlight,
+			article.Title,
+			article.Author,
+			article.Link,
+			readwiseNote(article.Notes, tags),
+			"",
+			readwiseDate(article),
+		})
+		if err != nil {
+			log.Printf("error writing readwise export: %v", err)
+			return
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("error writing readwise export: %v", err)
+	}
+}
+
+// readwiseNote is the note of an article's highlight: its notes, then its
+// tags.
+func readwiseNote(notes string, tags []string) string {
+	if len(tags) == 0 {
+		return notes
+	}
+	if notes == "" {
+		return readwiseTags(tags)
+	}
+	return notes + "\n" + readwiseTags(tags)
+}
+
+// readwiseTags writes tags the way Readwise reads them from a note: each
+// prefixed with a period, words joined by underscores.
+func readwiseTags(tags []string) string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		out = append(out, "."+strings.Join(strings.Fields(tag), "_"))
+	}
+	return strings.Join(out, " ")
+}
+
+// readwiseDate is when the article was finished, or saved if it wasn't,
+// in the format Readwise expects.
+func readwiseDate(article types.Article) string {
+	const readwiseDateFormat = "2006-01-02 15:04:05"
+
+	for _, value := range []string{article.CompletedAt, article.CreatedAt} {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t.UTC().Format(readwiseDateFormat)
+		}
+	}
+	if t, err := time.Parse("2006-01-02", article.DateRead); err == nil {
+		return t.Format(readwiseDateFormat)
+	}
+	return ""
+}
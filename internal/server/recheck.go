@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"log"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// linkRecheck is the outcome of the last scheduled check of unread links,
+// reported by /health.
+type linkRecheck struct {
+	mu       sync.Mutex
+	lastRun  time.Time
+	err      error
+	checked  int
+	flagged  int
+	archived int
+}
+
+func (l *linkRecheck) set(checked int, flagged int, archived int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastRun = time.Now()
+	l.checked, l.flagged, l.archived, l.err = checked, flagged, archived, err
+}
+
+// health adds the last run to the /health stats.
+func (l *linkRecheck) health(stats map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lastRun.IsZero() {
+		stats["link_recheck"] = "not run yet"
+		return
+	}
+	stats["link_recheck"] = "ok"
+	if l.err != nil {
+		stats["link_recheck"] = "failed: " + l.err.Error()
+	}
+	stats["link_recheck_last_run"] = l.lastRun.UTC().Format(time.RFC3339)
+	stats["link_recheck_checked"] = strconv.Itoa(l.checked)
+	stats["link_recheck_flagged"] = strconv.Itoa(l.flagged)
+	stats["link_recheck_archived"] = strconv.Itoa(l.archived)
+}
+
+// startLinkRecheck checks the links of unread articles every interval, so
+// the backlog is archived while it can still be read.
+func (s *Server) startLinkRecheck(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.recheckUnreadLinks()
+		}
+	}()
+}
+
+// recheckUnreadLinks checks every unread article's link and archives those
+// that fail and have no snapshot yet. Archiving needs ARCHIVE_ENABLED;
+// without it the failures are only recorded.
+func (s *Server) recheckUnreadLinks() {
+	ctx, cancel := context.WithTimeout(context.Background(), linkCheckTimeout)
+	defer cancel()
+
+	articles, err := s.store.GetArticlePage(database.ArticleFilter{Status: types.StatusUnread}, 0, -1)
+	if err != nil {
+		log.Printf("error listing unread articles for link recheck: %v", err)
+		s.linkRecheck.set(0, 0, 0, err)
+		return
+	}
+
+	resp, err := s.checkLinks(ctx, *articles)
+	if err != nil {
+		log.Printf("error rechecking unread links: %v", err)
+		s.linkRecheck.set(0, 0, 0, err)
+		return
+	}
+
+	byID := make(map[int]types.Article, len(*articles))
+	for _, article := range *articles {
+		byID[article.ID] = article
+	}
+	archived := 0
+	for _, res := range resp.Flagged {
+		if s.archiver == nil || byID[res.ID].ArchiveURL != "" {
+			continue
+		}
+		if s.archiveArticle(res.ID, res.Link) == nil {
+			archived++
+		}
+	}
+	log.Printf("link recheck: %d unread links checked, %d failing, %d archived", resp.Checked, len(resp.Flagged), archived)
+	s.linkRecheck.set(resp.Checked, len(resp.Flagged), archived, nil)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/go-chi/render"
+)
+
+// respond is installed as render.Respond. It behaves like
+// render.DefaultResponder, except that JSON is indented when the request
+// carries ?pretty=true and empty fields are dropped from sparse responses.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	pretty, sparse := wantsPretty(r), s.wantsSparse(r)
+	if (!pretty && !sparse) || render.GetAcceptedContentType(r) == render.ContentTypeXML {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+	// streamed responses are left to the default responder
+	if v != nil && reflect.TypeOf(v).Kind() == reflect.Chan {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+
+	data, err := json.Marshal(v)
+	if err == nil && sparse {
+		data, err = omitEmpty(data)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if !pretty || json.Indent(buf, data, "", "  ") != nil {
+		// Indent may have written part of the body before failing
+		buf.Reset()
+		buf.Write(data)
+	}
+	buf.WriteByte('\n')
+
+	w.Header().Set("Content-Type", "application/json")
+	if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+		w.WriteHeader(status)
+	}
+	w.Write(buf.Bytes())
+}
+
+func wantsPretty(r *http.Request) bool {
+	switch r.URL.Query().Get("pretty") {
+	case "true", "1":
+		return true
+	}
+	return false
+}
+
+// wantsSparse reports whether empty fields are left out of the response:
+// ?sparse=true or false when given, SPARSE_RESPONSES otherwise.
+func (s *Server) wantsSparse(r *http.Request) bool {
+	switch r.URL.Query().Get("sparse") {
+	case "true", "1":
+		return true
+	case "false", "0":
+		return false
+	}
+	return s.sparseResponses
+}
+
+// omitEmpty drops the empty strings, nulls, empty lists and empty objects
+// from every JSON object in data, keeping the order of the other fields.
+// Numbers and booleans stay even when zero or false, as those are values.
+func omitEmpty(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return data, nil
+	}
+	switch data[0] {
+	case '{':
+		var fields []struct {
+			key   string
+			value json.RawMessage
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+			value, err = omitEmpty(value)
+			if err != nil {
+				return nil, err
+			}
+			if isEmptyJSON(value) {
+				continue
+			}
+			fields = append(fields, struct {
+				key   string
+				value json.RawMessage
+			}{key.(string), value})
+		}
+
+		buf := &bytes.Buffer{}
+		buf.WriteByte('{')
+		for i, field := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(field.key)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(field.value)
+		}
+		buf.WriteByte('}')
+		return buf.Bytes(), nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		buf := &bytes.Buffer{}
+		buf.WriteByte('[')
+		for i, item := range items {
+			item, err := omitEmpty(item)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(item)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	}
+	return data, nil
+}
+
+func isEmptyJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case `""`, "null", "[]", "{}":
+		return true
+	}
+	return false
+}
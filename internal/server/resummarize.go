@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/render"
+	"go.opentelemetry.io/otel/attribute"
+
+	"reading-list-api/internal/exa"
+	"reading-list-api/internal/tracing"
+	"reading-list-api/internal/types"
+)
+
+const (
+	maxResummarizeIDs      = 100
+	resummarizeConcurrency = 4
+	defaultSummaryStyle    = "short"
+)
+
+// summaryStyles maps each summary style to the instruction given to the
+// model. short matches the summaries written at extraction time.
+var summaryStyles = map[string]string{
+	"short": "a single sentence of around 20 words or less",
+	"long":  "a paragraph of three to five sentences, around 80 words",
+}
+
+type ResummarizeRequest struct {
+	IDs   []int  `json:"ids"`
+	Style string `json:"style"`
+}
+
+func (a *ResummarizeRequest) Bind(r *http.Request) error {
+	if len(a.IDs) == 0 {
+		return errors.New("ids is required")
+	}
+	if len(a.IDs) > maxResummarizeIDs {
+		return fmt.Errorf("at most %d ids can be resummarized at once", maxResummarizeIDs)
+	}
+	if a.Style == "" {
+		a.Style = defaultSummaryStyle
+	}
+	if _, ok := summaryStyles[a.Style]; !ok {
+		return fmt.Errorf("unknown style %q, expected short or long", a.Style)
+	}
+	return nil
+}
+
+type ResummarizedArticle struct {
+	ID      int    `json:"id"`
+	Summary string `json:"summary"`
+}
+
+type ResummarizeFailure struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+type ResummarizeResponse struct {
+	Style   string                `json:"style"`
+	Updated []ResummarizedArticle `json:"updated"`
+	Failed  []ResummarizeFailure  `json:"failed"`
+}
+
+func (rd *ResummarizeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// ResummarizeHandler rewrites the summaries of the given articles in one
+// style, working from their stored content. Articles are summarized a few at
+// a time; ones that fail are reported and keep their old summary.
+func (s *Server) ResummarizeHandler(w http.ResponseWriter, r *http.Request) {
+	data := &ResummarizeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	exaClient, err := newExaClient()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	resp := &ResummarizeResponse{
+		Style:   data.Style,
+		Updated: make([]ResummarizedArticle, 0),
+		Failed:  make([]ResummarizeFailure, 0),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, resummarizeConcurrency)
+	seen := make(map[int]bool)
+	for _, id := range data.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			summary, err := s.resummarizeArticle(r.Context(), exaClient, id, data.Style)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				resp.Failed = append(resp.Failed, ResummarizeFailure{ID: id, Error: err.Error()})
+				return
+			}
+			resp.Updated = append(resp.Updated, ResummarizedArticle{ID: id, Summary: summary})
+		}(id)
+	}
+	wg.Wait()
+
+	render.Render(w, r, resp)
+}
+
+// resummarizeArticle regenerates one article's summary in the given style
+// from its stored content and saves it.
+func (s *Server) resummarizeArticle(ctx context.Context, exaClient *exa.Client, id int, style string) (string, error) {
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		return "", err
+	}
+	if article.Content == "" {
+		return "", errors.New("no stored content; it is only kept when STORE_CONTENT is enabled")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, exaTimeout)
+	defer cancel()
+	summary, err := summarizeContent(ctx, exaClient, article, style)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.db.SetSummary(id, summary); err != nil {
+		return "", err
+	}
+	updated := *article
+	updated.Summary = summary
+	s.recordHistory(article, &updated, ChangeResummarize)
+	if s.embedder != nil {
+		go s.embedArticle(id, embeddingText(&updated))
+	}
+	return summary, nil
+}
+
+// summarizeContent asks Exa to summarize the article's stored text.
+func summarizeContent(ctx context.Context, exaClient *exa.Client, article *types.Article, style string) (string, error) {
+	ctx, span := tracing.Start(ctx, "exa.answer", attribute.String("article.link", article.Link))
+	answerResp, err := exaClient.Answer(ctx, exa.AnswerRequest{
+		Query: fmt.Sprintf(
+			`Summarize the article below in %s.
+Return ONLY the summary text (no prose around it, no markdown, no quotes).
+
+Title: %s
+
+%s`,
+			summaryStyles[style], article.Title, article.Content,
+		),
+		Text: false,
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.Trim(strings.TrimSpace(answerResp.Answer), `"`)
+	if summary == "" {
+		return "", errors.New("summarizer returned an empty summary")
+	}
+	return summary, nil
+}
@@ -1,26 +1,36 @@
 package server
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"reading-list-api/internal/logging"
+	"reading-list-api/internal/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/go-chi/render"
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
+	render.Respond = s.respond
+
 	r := chi.NewRouter()
+	// Treat "/articles/" and "/articles" as the same route; the slash-less
+	// form is canonical.
+	r.Use(middleware.StripSlashes)
 
 	r.Get("/health", s.healthHandler)
+	r.Get("/metrics", s.MetricsHandler)
 
 	api := chi.NewRouter()
-	api.Use(middleware.Logger)
+	api.Use(tracing.Middleware)
+	api.Use(middleware.RequestID)
+	api.Use(logging.Middleware)
 	api.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
+		ExposedHeaders:   []string{TotalCountHeader, SnapshotHeader, ExtractorHeader, "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -31,9 +41,66 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// took parts from chi example: https://github.com/go-chi/chi/blob/master/_examples/rest/main.go
 	api.Route("/articles", func(r chi.Router) {
 		r.With(Paginate).Get("/", s.GetArticlesPageHandler)
+		r.With(Paginate).Head("/", s.HeadArticlesPageHandler)
 		r.Post("/", s.CreateArticle)
+		r.Post("/from-html", s.CreateArticleFromHTML)
+		r.Post("/estimate", s.EstimateExtractionHandler)
+		r.Post("/import/pocket", s.ImportPocketHandler)
+		r.Post("/import/bookmarks", s.ImportBookmarksHandler)
+		r.Post("/import.csv", s.ImportCSVHandler)
+		r.Get("/export.csv", s.ExportCSVHandler)
+		r.Get("/export.readwise.csv", s.ExportReadwiseHandler)
 		r.Get("/all", s.GetAllArticlesHandler)
+		r.Post("/by-ids", s.GetArticlesByIDsHandler)
+		r.Post("/status", s.SetStatusesHandler)
+		r.Get("/types", s.GetArticleTypesHandler)
+		r.Get("/stats", s.GetArticleStatsHandler)
+		r.Get("/sites", s.GetArticleSitesHandler)
+		r.Get("/stale", s.GetStaleArticlesHandler)
+		r.Get("/completed", s.GetCompletedArticlesHandler)
+		r.Get("/velocity", s.GetVelocityHandler)
+		r.With(Paginate).Get("/search", s.SearchArticlesHandler)
+		r.Get("/semantic-search", s.SemanticSearchHandler)
+		r.Post("/check-links", s.CheckLinksHandler)
+		r.Post("/normalize-links", s.NormalizeLinksHandler)
+		r.Post("/resummarize", s.ResummarizeHandler)
+		r.Get("/{id}", s.GetArticleByIDHandler)
+		r.Head("/{id}", s.HeadArticleByIDHandler)
+		r.Patch("/{id}", s.PatchArticleHandler)
+		r.Delete("/{id}", s.DeleteArticle)
+		r.Get("/{id}/raw", s.GetArticleRawHandler)
+		r.Post("/{id}/retry", s.RetryExtractionHandler)
+		r.Get("/{id}/similar-saved", s.GetSimilarSavedHandler)
+		r.Patch("/{id}/pin", s.TogglePinHandler)
+		r.Patch("/{id}/status", s.SetStatusHandler)
+		r.Patch("/{id}/read", s.MarkReadHandler)
+		r.Patch("/{id}/unread", s.MarkUnreadHandler)
+		r.Patch("/{id}/progress", s.SetProgressHandler)
+		r.Patch("/{id}/image", s.SetImageHandler)
+		r.Post("/{id}/tags", s.AddTagsHandler)
+		r.Delete("/{id}/tags/{tag}", s.RemoveTagHandler)
+		r.Get("/{id}/links", s.GetArticleLinksHandler)
+		r.Post("/{id}/links", s.AddArticleLinkHandler)
+		r.Get("/{id}/history", s.GetArticleHistoryHandler)
+		r.Post("/{id}/revert", s.RevertArticleHandler)
+
+	})
+
+	api.Route("/goals", func(r chi.Router) {
+		r.Get("/", s.GetGoalsHandler)
+		r.Put("/", s.SetGoalHandler)
+		r.Get("/progress", s.GetGoalProgressHandler)
+	})
 
+	api.Route("/admin", func(r chi.Router) {
+		r.Use(s.RequireAdmin)
+		r.Get("/maintenance", s.GetMaintenanceHandler)
+		r.Put("/maintenance", s.SetMaintenanceHandler)
+	})
+
+	api.Route("/debug", func(r chi.Router) {
+		r.Use(s.RequireAdmin)
+		r.Post("/extract-preview", s.ExtractPreviewHandler)
 	})
 
 	r.Mount("/", api)
@@ -42,37 +109,262 @@ func (s *Server) RegisterRoutes() http.Handler {
 }
 
 func (s *Server) HelloWorldHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
 	resp := map[string]map[string]string{
 		"GET /articles": {
+			"accepts":     "?page=integer, ?status=unread|reading|read|pending|processing|complete|failed (default complete), ?snapshot=token, ?inProgress=true (progress 1-99), ?site=site name or domain, ?type=0|1|2 (article, paper, book; a comma-separated list keeps any of them), ?author=text (authors containing it, ignoring case), ?tag=name (repeat to require several tags), ?sort=dateRead|createdAt|datePublished|title|id, ?order=asc|desc (defaults set by ARTICLE_SORT and ARTICLE_ORDER), ?cursor (empty to start, then a nextCursor)",
+			"returns":     `{totalArticles: integer, articles: [{id: integer, title: string, author: string, summary: string, dateRead: string, datePublished: string, link: string, img_path: string, type: integer}], snapshot: string, nextCursor: string}`,
+			"description": "Returns a page of articles. Pass the snapshot token from the first page (also in the X-Snapshot-Token header) as ?snapshot on later pages so newly added articles don't shift them. With ?cursor the listing is paged by position instead of page number: send ?cursor with no value for the first page, then each page's nextCursor for the one after it; the last page has none. A cursor only continues a listing in the sort and order it was made in",
+		},
+		"POST /articles": {
+			"accepts":     `{articleLink: string, title?: string, author?: string, summary?: string, datePublished?: string, type?: integer}`,
+			"returns":     `{id: integer, title: string, author: string, summary: string, dateRead: string, datePublished: string, link: string, img_path: string, type: integer, extractionStatus: string}`,
+			"description": "Saves the link as a pending article and returns 202 with the record; its metadata is extracted in the background, poll GET /articles/{id} until extractionStatus is complete or failed. New articles are unread until their status is set. Returns 503 with Retry-After when the extraction queue is full. With ?upsert=true an existing link is queued for re-extraction, keeping its dateRead, status, rating and notes and staying listed with its current metadata meanwhile; if that fails for good the article keeps its previous metadata, back to complete with extractionError set. ?retries=N overrides EXTRACT_MAX_ATTEMPTS with N retries after the first attempt, up to EXTRACT_MAX_RETRIES. With ?skipExtraction=true the supplied title, summary and other metadata are stored as-is and the saved article is returned straight away. When SERVER_FETCH=false only ?skipExtraction=true saves are accepted; send the page to POST /articles/from-html instead. Invalid input returns 400 with {status, error: \"validation\", fields: {articleLink: \"required\", ...}} naming each offending field",
+		},
+		"POST /articles/{id}/retry": {
+			"accepts":     "N/A",
+			"returns":     `{id: integer, title: string, ..., extractionStatus: "pending"}`,
+			"description": "Re-queues a failed extraction once its automatic retries have run out; list them with GET /articles?status=failed",
+		},
+		"GET /articles/export.csv": {
 			"accepts":     "N/A",
+			"returns":     "text/csv with columns id, link, title, author, summary, type, date_read, date_published, status, created_at, completed_at, pinned, site_name, rating, notes, tags",
+			"description": "Exports every article with its comma-separated tags, in the layout POST /articles/import.csv reads",
+		},
+		"GET /articles/export.readwise.csv": {
+			"accepts":     "?status=unread|reading|read",
+			"returns":     "text/csv with columns Highlight, Title, Author, URL, Note, Location, Date",
+			"description": "Exports articles for Readwise's CSV import, one highlight per article holding its summary, with its tags as .tag entries in the note",
+		},
+		"POST /articles/import.csv": {
+			"accepts":     "a CSV in the export layout (link required, other columns optional, id ignored) as the raw body or multipart field \"file\", ?extract=true to re-extract metadata",
+			"returns":     `{imported: integer, skipped: integer, failed: integer, errors: [{line: integer, link: string, error: string}]}`,
+			"description": "Restores articles from a CSV export in one transaction, storing its metadata as-is unless ?extract=true. Links already saved are skipped, so their stored rating, notes and tags are kept rather than replaced by the CSV's. If any row is invalid nothing is imported and the bad rows are listed by line with a 400",
+		},
+		"GET /articles/all": {
+			"accepts":     "?sort=dateRead|createdAt|datePublished|title|id, ?order=asc|desc",
+			"returns":     `[{id: integer, title: string, ...}]`,
+			"description": "Returns every article whose extraction is complete, pinned ones first. The array is streamed as it is read, so a response cut short by a server error is invalid JSON",
+		},
+		"GET /articles/search": {
+			"accepts":     "?q=string (required), ?page=integer",
+			"returns":     `{totalArticles: integer, articles: [{id: integer, title: string, ...}]}`,
+			"description": "Returns a page of the articles whose title, summary or author contain every word of q, the last word also as a prefix. Built with the sqlite_fts5 tag it uses a full-text index and ranks the best matches first; otherwise it matches substrings and lists the newest first",
+		},
+		"GET /articles/semantic-search": {
+			"accepts":     "?q=string, ?limit=integer (default 10, max 50)",
+			"returns":     `[{id: integer, title: string, ..., score: number}]`,
+			"description": "Ranks articles by how close their summary embeddings are to the query's, best match first. Requires EMBEDDINGS_ENABLED, 501 without it; articles saved before it was enabled are not included",
+		},
+		"GET /articles/{id}/similar-saved": {
+			"accepts":     "?limit=integer (default 10, max 50)",
+			"returns":     `[{id: integer, title: string, link: string, score: number}]`,
+			"description": "Returns the articles in your library most similar to this one by title and summary, best match first",
+		},
+		"GET /articles/{id}": {
+			"accepts":     "?wait=true to hold the request (up to 25s) until a pending article's extraction completes or fails; If-None-Match header with a previous ETag",
+			"returns":     `{id: integer, title: string, ..., extractionStatus: "pending" | "processing" | "complete" | "failed", extractionError: string, extractionAttempts: integer, typeUncertain: boolean, authorGuessed: boolean, titleGuessed: boolean, suggestedTags: [string]}`,
+			"description": "Returns a single article, including ones still being extracted. The X-Extractor header (also the extractor field) names what produced its metadata: exa-contents, exa-answer, html or client, with +pagemeta when gaps were filled from the page's meta tags. The tags field lists the article's tags. With AUTO_TAG, suggestedTags lists the tags extraction added, which the client can offer to remove with DELETE /articles/{id}/tags/{tag}. The ETag header changes with any field of the article; sending it back as If-None-Match returns 304 with no body while it is unchanged. HEAD returns the same headers, ETag included, without the body",
+		},
+		"PATCH /articles/{id}": {
+			"accepts":     `Content-Type: application/merge-patch+json or application/json with any of {title: string, author: string | null, summary: string | null, datePublished: string | null, type: integer | null, siteName: string | null, rating: integer 1-5 | null, notes: string | null}`,
+			"returns":     `{id: integer, title: string, ..., authorGuessed: boolean, titleGuessed: boolean, typeUncertain: boolean}`,
+			"description": "Edits an article's metadata as a JSON Merge Patch (RFC 7386): null clears a field (type goes back to 0, rating to unrated), absent keys are left unchanged. Any other Content-Type returns 415. A rating outside 1-5 returns 400. A title, author or type set here is no longer flagged as guessed or uncertain",
+		},
+		"DELETE /articles/{id}": {
+			"accepts":     "N/A",
+			"returns":     "204 with no body",
+			"description": "Deletes an article along with its tags, alternate links and edit history. Returns 404 when there is no article with that id",
+		},
+		"POST /articles/{id}/tags": {
+			"accepts":     `{tags: [string]}`,
+			"returns":     `{id: integer, title: string, ..., tags: [string]}`,
+			"description": "Adds tags to an article. Tags are trimmed, stored lowercase and de-duplicated; ones the article already has are ignored",
+		},
+		"DELETE /articles/{id}/tags/{tag}": {
+			"accepts":     "N/A",
+			"returns":     `{id: integer, title: string, ..., tags: [string]}`,
+			"description": "Removes a tag from an article, such as one of its suggestedTags. Returns 404 when the article doesn't have the tag",
+		},
+		"GET /articles/{id}/raw": {
+			"accepts":     "N/A",
+			"returns":     "text/markdown",
+			"description": "Returns the article text captured when it was extracted. Only articles saved with STORE_CONTENT enabled have it; others return 404",
+		},
+		"POST /articles/by-ids": {
+			"accepts":     `{ids: [integer]} (at most 500)`,
 			"returns":     `[{id: integer, title: string, author: string, summary: string, dateRead: string, datePublished: string, link: string, img_path: string, type: integer}]`,
-			"description": "Returns all the articles",
+			"description": "Returns the articles with the given ids in the order given. Ids with no article are left out",
 		},
-		"POST /articles": {
+		"POST /articles/status": {
+			"accepts":     `{ids: [integer], status: "unread" | "reading" | "read"} (at most 500 ids)`,
+			"returns":     `{status: string, updated: integer}`,
+			"description": "Sets the reading status of many articles in one transaction. Ids with no article are skipped and not counted in updated",
+		},
+		"POST /articles/estimate": {
 			"accepts":     `{articleLink: string}`,
+			"returns":     `{link: string, markdownBytes: integer, pageTokens: integer, inputTokens: integer, textTruncated: boolean, estimatedCost: number}`,
+			"description": "Fetches the page and estimates the tokens extracting it would use, without running the extraction. inputTokens counts the prompt and the part of the page Exa reads; estimatedCost (USD) is only set when EXTRACT_PRICE_PER_MTOK is",
+		},
+		"POST /articles/from-html": {
+			"accepts":     `{link: string, html: string}`,
 			"returns":     `{id: integer, title: string, author: string, summary: string, dateRead: string, datePublished: string, link: string, img_path: string, type: integer}`,
-			"description": "Adds a new article using the provided link and returns the saved article metadata",
+			"description": "Adds a new article from page HTML supplied by the client, for pages the server cannot fetch itself",
+		},
+		"POST /articles/import/pocket": {
+			"accepts":     "Pocket ril_export.html as the request body or the \"file\" field of a multipart form",
+			"returns":     `{imported: integer, skipped: integer, failed: integer, errors: [{link: string, error: string}]}`,
+			"description": "Imports a Pocket export, keeping each link's save date, read state and tags, and queues the new links for extraction. Links already saved are skipped",
+		},
+		"POST /articles/import/bookmarks": {
+			"accepts":     "Netscape-format bookmarks.html as the request body or the \"file\" field of a multipart form",
+			"returns":     `{imported: integer, skipped: integer, failed: integer, errors: [{link: string, error: string}]}`,
+			"description": "Imports browser bookmarks, dating each from when it was bookmarked and tagging it with its folders, and queues the new links for extraction. Links already saved are skipped",
+		},
+		"PATCH /articles/{id}/pin": {
+			"accepts":     `{sortOrder?: integer}`,
+			"returns":     `{id: integer, title: string, ..., pinned: boolean, sortOrder: integer}`,
+			"description": "Toggles whether the article is pinned to the top of the list, optionally setting its order among pinned articles",
+		},
+		"GET /articles/sites": {
+			"accepts":     "N/A",
+			"returns":     `[{siteName: string, count: integer}]`,
+			"description": "Returns the sites articles were saved from (og:site_name, or the domain) with their article counts, most saved first. Pass one to GET /articles?site= to browse it",
+		},
+		"GET /articles/types": {
+			"accepts":     "N/A",
+			"returns":     `[{type: integer, label: string, count: integer}]`,
+			"description": "Returns each article type with its label and the number of stored articles of that type",
+		},
+		"GET /articles/stats": {
+			"accepts":     "N/A",
+			"returns":     `{total: integer, byType: [{type: integer, label: string, count: integer}], readThisMonth: integer, earliestDateRead: string, latestDateRead: string}`,
+			"description": "Returns totals for a reading dashboard: the number of articles and of each type, how many were marked read since the start of the month (UTC), and the earliest and latest dateRead",
+		},
+		"PATCH /articles/{id}/status": {
+			"accepts":     `{status: "unread" | "reading" | "read"}`,
+			"returns":     `{id: integer, title: string, ..., status: string}`,
+			"description": "Sets the reading status of an article. Marking it read sets completedAt, and dateRead to today when it has none",
+		},
+		"PATCH /articles/{id}/read": {
+			"accepts":     "N/A",
+			"returns":     `{id: integer, title: string, ..., status: "read"}`,
+			"description": "Shortcut for PATCH /articles/{id}/status with status read",
+		},
+		"PATCH /articles/{id}/unread": {
+			"accepts":     "N/A",
+			"returns":     `{id: integer, title: string, ..., status: "unread"}`,
+			"description": "Shortcut for PATCH /articles/{id}/status with status unread",
+		},
+		"PATCH /articles/{id}/progress": {
+			"accepts":     `{progress: integer (0-100)}`,
+			"returns":     `{id: integer, title: string, ..., status: string, progress: integer}`,
+			"description": "Records how far through an article you are. Progress above 0 moves an unread article to reading",
+		},
+		"GET /articles/{id}/links": {
+			"accepts":     "N/A",
+			"returns":     `{links: [{id: integer, articleId: integer, link: string, label: string, createdAt: string}]}`,
+			"description": "Returns the alternate links (mirrors, archive copies, syndicated versions) of an article; its primary link stays in the article's link field",
+		},
+		"POST /articles/{id}/links": {
+			"accepts":     `{link: string, label?: string}`,
+			"returns":     `{id: integer, articleId: integer, link: string, label: string, createdAt: string}`,
+			"description": "Adds an alternate link to an article. Saving any of an article's links again, as a new article or another alternate, is a 409 conflict",
+		},
+		"PATCH /articles/{id}/image": {
+			"accepts":     `{imgPath: string (http(s) image url, "" to clear)}`,
+			"returns":     `{id: integer, title: string, ..., img_path: string}`,
+			"description": "Replaces the article's thumbnail with the given image",
+		},
+		"GET /articles/{id}/history": {
+			"accepts":     "N/A",
+			"returns":     `{changes: [{id: integer, articleId: integer, source: string, changedAt: string, revertOf?: integer, fields: [{field: string, oldValue: string, newValue: string}]}]}`,
+			"description": "Returns the recorded edits of an article, newest first: PATCH edits, re-extractions (refresh), resummarize, link normalization and reverts",
+		},
+		"POST /articles/{id}/revert": {
+			"accepts":     `{changeId: integer}`,
+			"returns":     `{id: integer, title: string, ...}`,
+			"description": "Restores the article to how it was before the given change, undoing it and every later change. The revert is recorded in the history too",
+		},
+		"GET /articles/stale": {
+			"accepts":     "?days=integer (default 90)",
+			"returns":     `[{id: integer, title: string, ..., createdAt: string, ageDays: integer}]`,
+			"description": "Returns unread articles added more than the given number of days ago, oldest first",
+		},
+		"GET /articles/completed": {
+			"accepts":     "?days=integer (default 30)",
+			"returns":     `[{id: integer, title: string, ..., completedAt: string}]`,
+			"description": "Returns articles marked read in the last given number of days, most recently finished first",
+		},
+		"GET /articles/velocity": {
+			"accepts":     "?granularity=day|week|month (default week)",
+			"returns":     `{granularity: string, series: [{period: string, added: integer, completed: integer}]}`,
+			"description": "Returns how many articles were added and completed in each period",
+		},
+		"POST /articles/check-links": {
+			"accepts":     "N/A",
+			"returns":     `{checked: integer, dead: integer, unreachable: integer, flagged: [{id: integer, link: string, status: string, statusCode: integer, error: string}]}`,
+			"description": "Checks every stored link, records its status and returns the ones that are dead or unreachable",
+		},
+		"GET /goals": {
+			"accepts":     "N/A",
+			"returns":     `[{type: integer, period: "month" | "year", target: integer}]`,
+			"description": "Returns the reading goals",
+		},
+		"PUT /goals": {
+			"accepts":     `{type: integer, period: "month" | "year" (default month), target: integer}`,
+			"returns":     `[{type: integer, period: string, target: integer}]`,
+			"description": "Sets how many articles of a type to finish each period, replacing the earlier target. A target of 0 removes the goal",
+		},
+		"GET /goals/progress": {
+			"accepts":     "N/A",
+			"returns":     `{goals: [{type: integer, label: string, period: string, periodStart: string, periodEnd: string, target: integer, completed: integer, daysRemaining: integer, met: boolean}]}`,
+			"description": "Returns the articles of each goal's type finished so far in the current period (UTC) against its target",
+		},
+		"GET /admin/maintenance": {
+			"accepts":     "Authorization: Bearer <ADMIN_TOKEN>",
+			"returns":     `{enabled: boolean, pending: integer}`,
+			"description": "Returns whether maintenance mode is on and how many articles are pending extraction",
+		},
+		"PUT /admin/maintenance": {
+			"accepts":     `{enabled: boolean} with Authorization: Bearer <ADMIN_TOKEN>`,
+			"returns":     `{enabled: boolean, pending: integer}`,
+			"description": "Pauses background extraction so new saves stay pending, or resumes it and processes the pending articles",
+		},
+		"POST /articles/normalize-links": {
+			"accepts":     "?dryRun=true to only report",
+			"returns":     `{checked: integer, dryRun: boolean, updated: [{id: integer, from: string, to: string}], duplicates: [{id: integer, link: string, normalized: string, duplicateOf: integer}], invalid: [{id: integer, link: string, error: string}]}`,
+			"description": "Rewrites stored links to their normalized form (lowercase host, no tracking parameters, fragment or trailing slash), reporting the ones that would duplicate another article instead of changing them. Links that aren't absolute http(s) URLs are listed as invalid",
+		},
+		"POST /articles/resummarize": {
+			"accepts":     `{ids: [integer], style: "short" | "long"} (at most 100 ids, style defaults to short)`,
+			"returns":     `{style: string, updated: [{id: integer, summary: string}], failed: [{id: integer, error: string}]}`,
+			"description": "Rewrites the summaries of the given articles in one style from their stored content (see STORE_CONTENT). Articles without stored content are reported as failed",
+		},
+		"POST /debug/extract-preview": {
+			"accepts":     `{link: string} with Authorization: Bearer <ADMIN_TOKEN>`,
+			"returns":     `{link: string, fetchStrategy: string, title: string, markdown: string, markdownBytes: integer, truncated: boolean, summaryQuery: string, summarySchema: object, fallbackPrompt: string}`,
+			"description": "Fetches the page and returns its markdown (cut at 100KB, see truncated) with the prompts an extraction would send to Exa, without calling the model",
 		},
 		"GET /health": {
 			"accepts":     "N/A",
 			"returns":     "Database health status",
-			"description": "Returns the health status of the database",
+			"description": "Returns the health status of the database. With LINK_RECHECK_INTERVAL_HOURS set it also reports the last recheck of unread links: link_recheck (ok, failed: <error> or not run yet), link_recheck_last_run and how many links were checked, flagged and archived. It always reports extraction_success_rate (n/a before any attempt) and extraction_attempts over the last EXTRACT_METRICS_WINDOW extractions, with extraction_failures_<reason> for fetch, parse, incomplete, not_article, timeout and other",
+		},
+		"GET /metrics": {
+			"accepts":     "N/A",
+			"returns":     "Prometheus text exposition",
+			"description": "The extraction success rate over the last EXTRACT_METRICS_WINDOW attempts as reading_list_extraction_success_rate, with reading_list_extraction_window_attempts and reading_list_extraction_window_failures by reason",
 		},
 	}
 
-	jsonResp, err := json.Marshal(resp)
-	if err != nil {
-		log.Printf("Error handling JSON marshal: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	w.Write(jsonResp)
+	render.Respond(w, r, resp)
 }
 
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
-	jsonResp, _ := json.Marshal(s.db.Health())
-	_, _ = w.Write(jsonResp)
+	stats := s.db.Health()
+	if s.linkRecheckEnabled {
+		s.linkRecheck.health(stats)
+	}
+	s.extractionStats.health(stats)
+	render.Respond(w, r, stats)
 }
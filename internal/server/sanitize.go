@@ -0,0 +1,106 @@
+package server
+
+import (
+	"html"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"reading-list-api/internal/types"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	headingPattern    = regexp.MustCompile(`^#+\s+`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	// extractors emit **bold** in summaries; underscores and backticks are
+	// left alone, as titles like __init__ or `defer` use them legitimately
+	boldPattern = regexp.MustCompile(`(^|[^\w*])\*\*(\S(?:[^*\n]*\S)?)\*\*`)
+)
+
+// titleSeparators split a title from a trailing site name, as in
+// "Some post | Hacker News".
+var titleSeparators = []string{" | ", " - ", " – ", " — ", " · ", " :: "}
+
+// defaultTitleSiteNames are site-name suffixes stripped even when they don't
+// match the link's domain.
+var defaultTitleSiteNames = []string{"hacker news", "medium", "substack", "arxiv.org", "github", "wikipedia"}
+
+// sanitizeText strips HTML tags, markdown bold and heading markers, decodes
+// entities and collapses whitespace.
+func sanitizeText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	s = boldPattern.ReplaceAllString(s, "$1$2")
+	s = headingPattern.ReplaceAllString(strings.TrimSpace(s), "")
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
+
+// stripTitleSiteName drops a trailing " | Site" from a title when the site
+// is a known name or matches the link's domain.
+func stripTitleSiteName(title string, link string, siteNames []string) string {
+	for _, sep := range titleSeparators {
+		i := strings.LastIndex(title, sep)
+		if i <= 0 {
+			continue
+		}
+		suffix := strings.ToLower(strings.TrimSpace(title[i+len(sep):]))
+		if isSiteName(suffix, link, siteNames) {
+			return strings.TrimSpace(title[:i])
+		}
+	}
+	return title
+}
+
+func isSiteName(suffix string, link string, siteNames []string) bool {
+	for _, name := range siteNames {
+		if suffix == name {
+			return true
+		}
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	compact := strings.NewReplacer(" ", "", "-", "", ".", "").Replace(suffix)
+	if compact == "" {
+		return false
+	}
+	for _, label := range strings.Split(strings.ToLower(u.Hostname()), ".") {
+		if label == compact || strings.NewReplacer("-", "").Replace(label) == compact {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeArticle cleans the extracted title, author and summary before
+// they are stored. It is a no-op with SANITIZE_METADATA=false.
+func (s *Server) sanitizeArticle(article *types.Article) {
+	if !s.sanitizeMetadata {
+		return
+	}
+	article.Title = sanitizeText(article.Title)
+	article.Author = sanitizeText(article.Author)
+	article.Summary = sanitizeText(article.Summary)
+	if s.titleSiteNames != nil {
+		article.Title = stripTitleSiteName(article.Title, article.Link, s.titleSiteNames)
+	}
+}
+
+// titleSiteNames reads the site names stripped from titles, nil unless
+// STRIP_TITLE_SITE_NAMES is set. TITLE_SITE_NAMES adds to the defaults.
+func titleSiteNames() []string {
+	if !envBool("STRIP_TITLE_SITE_NAMES", false) {
+		return nil
+	}
+	names := append([]string{}, defaultTitleSiteNames...)
+	for _, name := range strings.Split(os.Getenv("TITLE_SITE_NAMES"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
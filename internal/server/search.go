@@ -0,0 +1,47 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reading-list-api/internal/types"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// SearchArticlesHandler pages through the articles whose title, summary or
+// author contain every word of ?q.
+func (s *Server) SearchArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	page := r.Context().Value(PageCtxKey).(int)
+	pageSize := r.Context().Value(PageSizeCtxKey).(int)
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		render.Render(w, r, ErrInvalidRequest(errors.New("q is required")))
+		return
+	}
+
+	total, err := s.db.GetSearchCount(q)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(fmt.Errorf("error counting search results: %v", err)))
+		return
+	}
+
+	articles := &[]types.Article{}
+	if offset := (page - 1) * pageSize; offset < total {
+		articles, err = s.db.SearchArticles(q, offset, pageSize)
+		if err != nil {
+			render.Render(w, r, ErrInternalServer(err))
+			return
+		}
+	}
+
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	err = render.Render(w, r, NewArticlePageResponse(articles, total))
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reading-list-api/internal/embed"
+	"reading-list-api/internal/types"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	embedTimeout         = 30 * time.Second
+	defaultSemanticLimit = 10
+	maxSemanticLimit     = 50
+)
+
+// embeddingText is what gets embedded for an article.
+func embeddingText(article *types.Article) string {
+	return strings.TrimSpace(article.Title + ". " + article.Summary)
+}
+
+// embedArticle stores the embedding of a newly extracted article. It runs
+// detached from the request, so failures are only logged; the article just
+// stays out of semantic search.
+func (s *Server) embedArticle(id int, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), embedTimeout)
+	defer cancel()
+
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		log.Printf("error embedding article %d: %v", id, err)
+		return
+	}
+	if err := s.db.SetEmbedding(id, embed.Encode(vectors[0])); err != nil {
+		log.Printf("error saving embedding for article %d: %v", id, err)
+	}
+}
+
+type SemanticResultResponse struct {
+	*types.Article
+	Score float64 `json:"score"`
+}
+
+func (rd *SemanticResultResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// SemanticSearchHandler embeds ?q and ranks stored articles by the cosine
+// similarity of their summary embeddings, best match first.
+func (s *Server) SemanticSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if s.embedder == nil {
+		render.Render(w, r, ErrNotImplemented(errors.New("semantic search is disabled, set EMBEDDINGS_ENABLED to enable it")))
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		render.Render(w, r, ErrInvalidRequest(errors.New("missing query parameter q")))
+		return
+	}
+	limit := defaultSemanticLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSemanticLimit {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid limit: %s, must be between 1 and %d", limitStr, maxSemanticLimit)))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), embedTimeout)
+	defer cancel()
+	vectors, err := s.embedder.Embed(ctx, []string{q})
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(fmt.Errorf("error embedding query: %v", err)))
+		return
+	}
+	query := vectors[0]
+
+	articles, err := s.db.GetEmbeddedArticles()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	results := make([]*SemanticResultResponse, 0)
+	for i := range *articles {
+		article := &(*articles)[i]
+		if len(article.Embedding) == 0 {
+			continue
+		}
+		score := embed.Cosine(query, embed.Decode(article.Embedding))
+		results = append(results, &SemanticResultResponse{Article: article, Score: score})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	list := make([]render.Renderer, 0, len(results))
+	for _, result := range results {
+		list = append(list, result)
+	}
+	err = render.RenderList(w, r, list)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -2,28 +2,230 @@ package server
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"reading-list-api/internal/archive"
 	"reading-list-api/internal/database"
+	"reading-list-api/internal/embed"
+	"reading-list-api/internal/fetch"
 )
 
 type Server struct {
 	port int
 
-	db database.Service
+	// store is what the article, tag, history, status change, import and
+	// extraction handlers use; db, the same database in production, serves
+	// search, stats and reports, goals, links and embeddings. Tests can run
+	// the store handlers on a database.Memory store alone.
+	store database.Store
+	db    database.Service
+
+	// fetcher downloads article pages directly, bypassing Exa
+	fetcher *fetch.Client
+	// fetchStrategy picks how pages are fetched: static, exa or auto
+	fetchStrategy string
+	// hostLimiter spaces out fetches to the same host across the page
+	// fetcher and the link checker; nil when FETCH_HOST_INTERVAL_MS is 0
+	hostLimiter *fetch.HostLimiter
+	// serverFetch is false for deployments that never fetch links, directly
+	// or through Exa, and only take pages clients upload
+	serverFetch bool
+	// extractor turns a link into article metadata for the extraction
+	// workers; Exa in production
+	extractor MetadataExtractor
+
+	// archiver is nil unless ARCHIVE_ENABLED is set
+	archiver *archive.Client
+
+	// embedder is nil unless EMBEDDINGS_ENABLED is set
+	embedder *embed.Client
+
+	// typeConfidenceThreshold flags extracted types below this confidence
+	// as uncertain
+	typeConfidenceThreshold float64
+
+	// extractPricePerMTok prices the input tokens of an extraction, in USD
+	// per million, for POST /articles/estimate; 0 leaves the cost out
+	extractPricePerMTok float64
+
+	// defaultOrder sorts listings that don't pass ?sort or ?order
+	defaultOrder database.ArticleOrder
+
+	// sanitizeMetadata cleans markup out of extracted titles, authors and
+	// summaries; titleSiteNames, when set, are stripped from title ends
+	sanitizeMetadata bool
+	titleSiteNames   []string
+
+	// onPartial decides whether extracted articles missing optional fields
+	// are saved or rejected
+	onPartial string
+
+	// autoTag has extraction suggest topical tags for each article
+	autoTag bool
+
+	// titleFromURL makes a title from the link's path for pages that give
+	// none
+	titleFromURL bool
+
+	// strictAuthor leaves unknown authors empty instead of guessing them
+	strictAuthor bool
+
+	// sparseResponses leaves empty fields out of JSON responses unless the
+	// request passes ?sparse=false
+	sparseResponses bool
+
+	// storeContent keeps the extracted article text for GET /articles/{id}/raw
+	storeContent bool
+
+	// maxArticles caps the library size; 0 means unlimited
+	maxArticles int
+
+	// adminToken guards the /admin routes; they are disabled when empty
+	adminToken string
+
+	// extractQueue feeds pending article ids to extractWorkers workers
+	extractQueue   chan int
+	extractWorkers int
+	// extractMaxAttempts caps automatic retries of a failed extraction;
+	// extractMaxRetries bounds the per-request ?retries override
+	extractMaxAttempts int
+	extractMaxRetries  int
+	// extractRetryBudget is the retries all Exa calls of one extraction
+	// attempt share; answerRetries caps those of the answer fallback, as
+	// FETCH_RETRIES does for the contents call
+	extractRetryBudget int
+	answerRetries      int
+
+	// saving and queueing dedupe concurrent saves of the same link
+	saving   inflightSaves
+	queueing inflightSaves
+
+	// extractionDone wakes long-polling GET /articles/{id}?wait=true
+	// requests when an extraction attempt ends
+	extractionDone extractionWaiters
+
+	// createArticleTimeout bounds everything a synchronous save does, from
+	// the duplicate check to the insert
+	createArticleTimeout time.Duration
+
+	// syncExtractions caps extractions run inside a request; beyond it they
+	// get a 503, or wait for a slot when syncExtractWait is set
+	syncExtractions chan struct{}
+	syncExtractWait bool
+
+	// pageCache serves repeated GET /articles pages between writes
+	pageCache *pageCache
+
+	// linkRecheck reports the last scheduled check of unread links; it only
+	// runs when LINK_RECHECK_INTERVAL_HOURS is set
+	linkRecheckEnabled bool
+	linkRecheck        linkRecheck
+
+	// extractionStats keeps the outcomes of the latest extraction attempts
+	// for /health and /metrics
+	extractionStats *extractionStats
+
+	// maintenance holds new saves as pending instead of dispatching them
+	maintenance atomic.Bool
 }
 
-func NewServer() *http.Server {
+// newServer configures a server from the environment around store and
+// extractor, Exa when it is nil. It opens nothing and starts no background
+// work, so tests can run handlers and workers on a database.Memory store
+// with a stub extractor; NewServer adds the database, clients and workers.
+func newServer(store database.Store, extractor MetadataExtractor) *Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
-	NewServer := &Server{
+	defaultOrder, err := database.ParseArticleOrder(os.Getenv("ARTICLE_SORT"), os.Getenv("ARTICLE_ORDER"))
+	if err != nil {
+		log.Fatalf("invalid default article order: %v", err)
+	}
+	hostLimiter := fetch.NewHostLimiter(time.Duration(envInt("FETCH_HOST_INTERVAL_MS", defaultFetchHostIntervalMs)) * time.Millisecond)
+	s := &Server{
 		port: port,
 
-		db: database.New(),
+		store:         store,
+		fetcher:       fetch.NewClient(fetch.ClientConfig{InsecureHosts: insecureHosts(), HostLimiter: hostLimiter}),
+		hostLimiter:   hostLimiter,
+		fetchStrategy: fetchStrategy(),
+		serverFetch:   envBool("SERVER_FETCH", true),
+		defaultOrder:  defaultOrder,
+
+		maxArticles:             envInt("MAX_ARTICLES", 0),
+		typeConfidenceThreshold: envFloat("TYPE_CONFIDENCE_THRESHOLD", defaultTypeConfidenceThreshold),
+		extractPricePerMTok:     envFloat("EXTRACT_PRICE_PER_MTOK", 0),
+		adminToken:              os.Getenv("ADMIN_TOKEN"),
+		storeContent:            envBool("STORE_CONTENT", false),
+		sparseResponses:         envBool("SPARSE_RESPONSES", false),
+		strictAuthor:            envBool("STRICT_AUTHOR", false),
+		titleFromURL:            envBool("TITLE_FROM_URL", true),
+		onPartial:               onPartial(),
+		autoTag:                 envBool("AUTO_TAG", false),
+		sanitizeMetadata:        envBool("SANITIZE_METADATA", true),
+		titleSiteNames:          titleSiteNames(),
+		extractQueue:            make(chan int, max(envInt("EXTRACT_QUEUE_SIZE", defaultExtractQueueSize), 1)),
+		extractWorkers:          max(envInt("EXTRACT_WORKERS", defaultExtractWorkers), 1),
+
+		extractMaxAttempts: max(envInt("EXTRACT_MAX_ATTEMPTS", defaultExtractMaxAttempts), 1),
+		extractMaxRetries:  max(envInt("EXTRACT_MAX_RETRIES", defaultExtractMaxRetries), 0),
+		extractRetryBudget: envInt("EXTRACT_RETRY_BUDGET", defaultExtractRetryBudget),
+		answerRetries:      max(envInt("ANSWER_RETRIES", envInt("FETCH_RETRIES", defaultFetchRetries)), 0),
+
+		syncExtractions: make(chan struct{}, max(envInt("SYNC_EXTRACT_LIMIT", defaultSyncExtractLimit), 1)),
+		syncExtractWait: envBool("SYNC_EXTRACT_WAIT", false),
+
+		createArticleTimeout: time.Duration(max(envInt("CREATE_ARTICLE_TIMEOUT_MS", defaultCreateArticleTimeoutMs), 1)) * time.Millisecond,
+
+		pageCache: newPageCache(time.Duration(envInt("PAGE_CACHE_TTL_MS", defaultPageCacheTTLMs)) * time.Millisecond),
+
+		extractionStats: newExtractionStats(envInt("EXTRACT_METRICS_WINDOW", defaultExtractMetricsWindow)),
+	}
+	s.maintenance.Store(envBool("MAINTENANCE_MODE", false))
+	s.extractor = extractor
+	if s.extractor == nil {
+		s.extractor = exaExtractor{s}
+	}
+	return s
+}
+
+func NewServer() *http.Server {
+	db := database.New()
+	NewServer := newServer(db, nil)
+	NewServer.db = db
+
+	if envBool("ARCHIVE_ENABLED", false) {
+		NewServer.archiver = archive.NewClient(archive.ClientConfig{})
+	}
+
+	if envBool("EMBEDDINGS_ENABLED", false) {
+		embedder, err := embed.NewClient(embed.ClientConfig{
+			APIKey:  os.Getenv("EMBEDDINGS_API_KEY"),
+			Model:   os.Getenv("EMBEDDINGS_MODEL"),
+			BaseURL: os.Getenv("EMBEDDINGS_BASE_URL"),
+
+			MaxResponseBytes: int64(envInt("UPSTREAM_MAX_RESPONSE_BYTES", 0)),
+		})
+		if err != nil {
+			log.Printf("embeddings disabled: %v", err)
+		} else {
+			NewServer.embedder = embedder
+		}
+	}
+
+	// without fetching there is nothing for the extraction workers to do;
+	// articles left pending stay that way until it is enabled again
+	if NewServer.serverFetch {
+		NewServer.startExtractors()
+	}
+	if hours := envInt("LINK_RECHECK_INTERVAL_HOURS", 0); hours > 0 && NewServer.serverFetch {
+		NewServer.linkRecheckEnabled = true
+		NewServer.startLinkRecheck(time.Duration(hours) * time.Hour)
 	}
 
 	// Declare Server config
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http/httptest"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"strings"
+	"testing"
+)
+
+// newTestServer returns a server on an empty in-memory store, extracting
+// with extractor, with no workers running.
+func newTestServer(t *testing.T, extractor MetadataExtractor) (*Server, *database.Memory) {
+	t.Helper()
+	store := database.NewMemory()
+	return newServer(store, extractor), store
+}
+
+// serve sends a request with an optional JSON body through the server's
+// routes and returns the recorded response.
+func serve(s *Server, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.RegisterRoutes().ServeHTTP(w, req)
+	return w
+}
+
+// insertTestArticle saves a complete article with the given title under a
+// link made from it, applying any changes first.
+func insertTestArticle(t *testing.T, store database.Store, title string, changes ...func(*types.Article)) *types.Article {
+	t.Helper()
+	article := &types.Article{
+		Title:    title,
+		Summary:  "summary of " + title,
+		DateRead: "2024-05-01",
+		Link:     "https://example.com/" + title,
+	}
+	for _, change := range changes {
+		change(article)
+	}
+	if err := store.InsertArticle(article); err != nil {
+		t.Fatalf("InsertArticle(%q): %v", title, err)
+	}
+	return article
+}
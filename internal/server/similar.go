@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/textsim"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 50
+)
+
+type SimilarArticleResponse struct {
+	ID    int     `json:"id"`
+	Title string  `json:"title"`
+	Link  string  `json:"link"`
+	Score float64 `json:"score"`
+}
+
+func (rd *SimilarArticleResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetSimilarSavedHandler ranks the other articles in the library by how
+// similar their title and summary are to the given article's, using TF-IDF
+// cosine similarity. Articles with nothing in common are left out.
+func (s *Server) GetSimilarSavedHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	limit := defaultSimilarLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSimilarLimit {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid limit: %s, must be between 1 and %d", limitStr, maxSimilarLimit)))
+			return
+		}
+	}
+
+	target, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	articles, err := s.store.GetAllArticles(database.ArticleOrder{})
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	// the target goes first; it may not be in the listing if it is still
+	// being extracted
+	docs := []string{target.Title + " " + target.Summary}
+	for _, a := range *articles {
+		docs = append(docs, a.Title+" "+a.Summary)
+	}
+	corpus := textsim.NewCorpus(docs)
+
+	similar := make([]*SimilarArticleResponse, 0)
+	for i, a := range *articles {
+		if a.ID == target.ID {
+			continue
+		}
+		score := corpus.Similarity(0, i+1)
+		if score == 0 {
+			continue
+		}
+		similar = append(similar, &SimilarArticleResponse{ID: a.ID, Title: a.Title, Link: a.Link, Score: score})
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Score > similar[j].Score
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	list := make([]render.Renderer, 0, len(similar))
+	for _, item := range similar {
+		list = append(list, item)
+	}
+	err = render.RenderList(w, r, list)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type StatusRequest struct {
+	Status string `json:"status"`
+}
+
+func (a *StatusRequest) Bind(r *http.Request) error {
+	if !types.ValidStatus(a.Status) {
+		return fmt.Errorf("invalid status %q, must be one of unread, reading, read", a.Status)
+	}
+	return nil
+}
+
+func (s *Server) SetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &StatusRequest{}
+	err = render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+	s.setStatus(w, r, id, data.Status)
+}
+
+// MarkReadHandler is a shortcut for setting an article's status to read.
+func (s *Server) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	s.setStatus(w, r, id, types.StatusRead)
+}
+
+// MarkUnreadHandler is a shortcut for setting an article's status to unread.
+func (s *Server) MarkUnreadHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	s.setStatus(w, r, id, types.StatusUnread)
+}
+
+// setStatus moves an article to status and renders it.
+func (s *Server) setStatus(w http.ResponseWriter, r *http.Request, id int, status string) {
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = s.store.SetStatus(id, status)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordHistory(before, article, ChangeStatus)
+
+	s.renderArticle(w, r, article)
+}
+
+// maxBulkStatusIDs caps the articles one POST /articles/status changes.
+const maxBulkStatusIDs = 500
+
+type BulkStatusRequest struct {
+	IDs    []int  `json:"ids"`
+	Status string `json:"status"`
+}
+
+func (a *BulkStatusRequest) Bind(r *http.Request) error {
+	if len(a.IDs) == 0 {
+		return errors.New("ids is required")
+	}
+	if len(a.IDs) > maxBulkStatusIDs {
+		return fmt.Errorf("at most %d articles can be changed at once", maxBulkStatusIDs)
+	}
+	if !types.ValidStatus(a.Status) {
+		return fmt.Errorf("invalid status %q, must be one of unread, reading, read", a.Status)
+	}
+	slices.Sort(a.IDs)
+	a.IDs = slices.Compact(a.IDs)
+	return nil
+}
+
+type BulkStatusResponse struct {
+	Status  string `json:"status"`
+	Updated int    `json:"updated"`
+}
+
+func (rd *BulkStatusResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// SetStatusesHandler moves many articles to one reading status at once, in
+// a single transaction. Ids with no article are skipped; updated counts the
+// rest.
+func (s *Server) SetStatusesHandler(w http.ResponseWriter, r *http.Request) {
+	data := &BulkStatusRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticlesByIDs(data.IDs)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	updated, err := s.store.SetStatuses(data.IDs, data.Status)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	after, err := s.store.GetArticlesByIDs(data.IDs)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	byID := make(map[int]*types.Article, len(*before))
+	for i := range *before {
+		byID[(*before)[i].ID] = &(*before)[i]
+	}
+	for i := range *after {
+		s.recordHistory(byID[(*after)[i].ID], &(*after)[i], ChangeStatus)
+	}
+
+	err = render.Render(w, r, &BulkStatusResponse{Status: data.Status, Updated: updated})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+type ProgressRequest struct {
+	Progress *int `json:"progress"`
+}
+
+func (a *ProgressRequest) Bind(r *http.Request) error {
+	if a.Progress == nil {
+		return errors.New("progress is required")
+	}
+	if *a.Progress < 0 || *a.Progress > 100 {
+		return fmt.Errorf("invalid progress %d, must be between 0 and 100", *a.Progress)
+	}
+	return nil
+}
+
+// SetProgressHandler records how far through an article the reader is. An
+// unread article with progress above 0 becomes reading.
+func (s *Server) SetProgressHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &ProgressRequest{}
+	err = render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = s.store.SetProgress(id, *data.Progress)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordHistory(before, article, ChangeProgress)
+
+	s.renderArticle(w, r, article)
+}
+
+type ImageRequest struct {
+	ImagePath *string `json:"imgPath"`
+}
+
+// imageExtensions are the file extensions accepted on an image URL. URLs
+// without an extension are accepted too, as image CDNs often leave it off.
+var imageExtensions = []string{".avif", ".gif", ".jpeg", ".jpg", ".png", ".svg", ".webp"}
+
+func (a *ImageRequest) Bind(r *http.Request) error {
+	if a.ImagePath == nil {
+		return errors.New("imgPath is required")
+	}
+	// an empty imgPath clears the image
+	if *a.ImagePath == "" {
+		return nil
+	}
+	u, err := url.Parse(*a.ImagePath)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("imgPath must be an absolute http(s) url: %s", *a.ImagePath)
+	}
+	if ext := strings.ToLower(path.Ext(u.Path)); ext != "" && !slices.Contains(imageExtensions, ext) {
+		return fmt.Errorf("imgPath must point to an image, got a %s file", ext)
+	}
+	return nil
+}
+
+// SetImageHandler replaces an article's thumbnail with an image of the
+// reader's choosing.
+func (s *Server) SetImageHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &ImageRequest{}
+	err = render.Bind(r, data)
+	if err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	before, err := s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = s.store.SetImagePath(id, *data.ImagePath)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.recordHistory(before, article, ChangeImage)
+
+	s.renderArticle(w, r, article)
+}
+
+type StaleArticleResponse struct {
+	*types.Article
+	AgeDays int `json:"ageDays"`
+}
+
+func (rd *StaleArticleResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetStaleArticlesHandler lists unread articles added more than ?days ago
+// (default 90), oldest first.
+func (s *Server) GetStaleArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	days := 90
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid days: %s", daysStr)))
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -days).Format(time.RFC3339)
+	articles, err := s.db.GetStaleArticles(cutoff)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	list := []render.Renderer{}
+	for i := range *articles {
+		article := &(*articles)[i]
+		list = append(list, &StaleArticleResponse{
+			Article: article,
+			AgeDays: ageInDays(article.CreatedAt, now),
+		})
+	}
+
+	err = render.RenderList(w, r, list)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// ageInDays accepts both RFC 3339 timestamps and the plain dates backfilled
+// for rows that predate created_at.
+func ageInDays(createdAt string, now time.Time) int {
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", createdAt)
+		if err != nil {
+			return 0
+		}
+	}
+	return int(now.Sub(t).Hours() / 24)
+}
+
+// GetCompletedArticlesHandler lists articles finished in the last ?days days
+// (default 30), most recently finished first.
+func (s *Server) GetCompletedArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid days: %s", daysStr)))
+			return
+		}
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -days).Format(time.RFC3339)
+	articles, err := s.db.GetCompletedArticles(since)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	s.renderArticleList(w, r, articles)
+}
+
+type VelocityResponse struct {
+	Granularity string                `json:"granularity"`
+	Series      []types.VelocityPoint `json:"series"`
+}
+
+func (rd *VelocityResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetVelocityHandler returns added-vs-completed counts per ?granularity
+// period (day, week or month; default week).
+func (s *Server) GetVelocityHandler(w http.ResponseWriter, r *http.Request) {
+	granularity := r.URL.Query().Get("granularity")
+	switch granularity {
+	case "":
+		granularity = "week"
+	case "day", "week", "month":
+	default:
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid granularity %q, must be day, week or month", granularity)))
+		return
+	}
+
+	series, err := s.db.GetVelocity(granularity)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	err = render.Render(w, r, &VelocityResponse{Granularity: granularity, Series: series})
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
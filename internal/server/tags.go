@@ -0,0 +1,138 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reading-list-api/internal/database"
+	"reading-list-api/internal/types"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// maxTagLength caps a single tag name.
+const maxTagLength = 64
+
+type AddTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (a *AddTagsRequest) Bind(r *http.Request) error {
+	if len(a.Tags) == 0 {
+		return errors.New("tags is required")
+	}
+	for _, tag := range a.Tags {
+		if len(tag) > maxTagLength {
+			return fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+	}
+	return nil
+}
+
+// articleWithTags renders an article along with its tags.
+func (s *Server) articleWithTags(article *types.Article) (*ArticleResponse, error) {
+	tags, err := s.store.GetTagsForArticle(article.ID)
+	if err != nil {
+		return nil, err
+	}
+	return NewArticleResponse(article, tags), nil
+}
+
+// renderArticle renders an article along with its tags.
+func (s *Server) renderArticle(w http.ResponseWriter, r *http.Request, article *types.Article) {
+	resp, err := s.articleWithTags(article)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	err = render.Render(w, r, resp)
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// renderArticleList renders articles along with their tags.
+func (s *Server) renderArticleList(w http.ResponseWriter, r *http.Request, articles *[]types.Article) {
+	tags, err := s.store.GetTagsByArticle()
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	err = render.RenderList(w, r, NewArticleListResponse(articles, tags))
+	if err != nil {
+		render.Render(w, r, ErrRender(err))
+		return
+	}
+}
+
+// renderArticleWithTags re-reads an article after a tag change and renders
+// it with its tags.
+func (s *Server) renderArticleWithTags(w http.ResponseWriter, r *http.Request, id int) {
+	article, err := s.store.GetArticleByID(id)
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.renderArticle(w, r, article)
+}
+
+// AddTagsHandler tags an article. Tags are stored lowercase, and ones the
+// article already has are ignored.
+func (s *Server) AddTagsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	data := &AddTagsRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, ErrBind(err))
+		return
+	}
+
+	_, err = s.store.GetArticleByID(id)
+	if errors.Is(err, database.ErrArticleNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+
+	if err := s.store.AddTags(id, data.Tags); err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.renderArticleWithTags(w, r, id)
+}
+
+// RemoveTagHandler takes a tag off an article, 404 when the article doesn't
+// have it.
+func (s *Server) RemoveTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := articleIDParam(r)
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	tag, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid tag: %v", err)))
+		return
+	}
+
+	err = s.store.RemoveTag(id, tag)
+	if errors.Is(err, database.ErrTagNotFound) {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInternalServer(err))
+		return
+	}
+	s.renderArticleWithTags(w, r, id)
+}
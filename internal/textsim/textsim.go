@@ -0,0 +1,93 @@
+// Package textsim scores how similar short texts are using TF-IDF weighted
+// cosine similarity. It is meant for small corpora like a personal library,
+// where building the vectors on every query is cheap.
+package textsim
+
+import (
+	"math"
+	"reading-list-api/internal/embed"
+	"strings"
+	"unicode"
+)
+
+// Vector is a sparse term-weight vector.
+type Vector map[string]float64
+
+// Corpus holds TF-IDF vectors for a set of documents.
+type Corpus struct {
+	docs []Vector
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "how": true, "in": true,
+	"is": true, "it": true, "its": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true,
+	"what": true, "why": true, "with": true, "you": true, "your": true,
+}
+
+// Tokenize lowercases text and splits it into words, dropping stopwords and
+// single characters.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) > 1 && !stopwords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+// NewCorpus builds the TF-IDF vectors for docs, in the same order.
+func NewCorpus(docs []string) *Corpus {
+	counts := make([]map[string]int, len(docs))
+	df := make(map[string]int)
+	for i, doc := range docs {
+		counts[i] = make(map[string]int)
+		for _, tok := range Tokenize(doc) {
+			if counts[i][tok] == 0 {
+				df[tok]++
+			}
+			counts[i][tok]++
+		}
+	}
+
+	n := float64(len(docs))
+	c := &Corpus{docs: make([]Vector, len(docs))}
+	for i, tf := range counts {
+		vec := make(Vector, len(tf))
+		for term, count := range tf {
+			// smoothed idf keeps terms found in every document above zero
+			idf := math.Log((1+n)/(1+float64(df[term]))) + 1
+			vec[term] = float64(count) * idf
+		}
+		c.docs[i] = vec
+	}
+	return c
+}
+
+// Similarity is the cosine similarity of documents i and j, from 0 to 1.
+func (c *Corpus) Similarity(i, j int) float64 {
+	return cosine(c.docs[i], c.docs[j])
+}
+
+// cosine is the cosine similarity of two sparse vectors, aligned term by
+// term into dense ones for embed.Cosine.
+func cosine(a, b Vector) float64 {
+	dense := make(map[string]int, len(a)+len(b))
+	for _, v := range []Vector{a, b} {
+		for term := range v {
+			if _, ok := dense[term]; !ok {
+				dense[term] = len(dense)
+			}
+		}
+	}
+	va, vb := make([]float32, len(dense)), make([]float32, len(dense))
+	for term, i := range dense {
+		va[i], vb[i] = float32(a[term]), float32(b[term])
+	}
+	return embed.Cosine(va, vb)
+}
@@ -1,5 +1,64 @@
 package types
 
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// Reading statuses of an article.
+const (
+	StatusUnread  = "unread"
+	StatusReading = "reading"
+	StatusRead    = "read"
+)
+
+// Extraction statuses of an article saved for background extraction.
+const (
+	ExtractionPending    = "pending"
+	ExtractionProcessing = "processing"
+	ExtractionComplete   = "complete"
+	ExtractionFailed     = "failed"
+)
+
+// ValidExtractionStatus reports whether status is one of the extraction
+// statuses.
+func ValidExtractionStatus(status string) bool {
+	switch status {
+	case ExtractionPending, ExtractionProcessing, ExtractionComplete, ExtractionFailed:
+		return true
+	}
+	return false
+}
+
+// Article types, as returned by extraction. TypeNotArticle marks links that
+// are rejected rather than stored.
+const (
+	TypeNotArticle = -1
+	TypeArticle    = 0
+	TypePaper      = 1
+	TypeBook       = 2
+)
+
+// KnownTypes lists the storable article types in display order.
+var KnownTypes = []int{TypeArticle, TypePaper, TypeBook}
+
+// TypeLabels names each storable article type.
+var TypeLabels = map[int]string{
+	TypeArticle: "Article",
+	TypePaper:   "Paper",
+	TypeBook:    "Book",
+}
+
+// ValidStatus reports whether status is one of the reading statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusUnread, StatusReading, StatusRead:
+		return true
+	}
+	return false
+}
+
 type Article struct {
 	ID            int    `db:"id" json:"id"`
 	Title         string `db:"title" json:"title"`
@@ -8,6 +67,153 @@ type Article struct {
 	DateRead      string `db:"date_read" json:"dateRead"`
 	DatePublished string `db:"date_published" json:"datePublished"`
 	Link          string `db:"link" json:"link"`
+	OriginalLink  string `db:"original_link" json:"originalLink"`
 	ImagePath     string `db:"img_path" json:"img_path"`
 	Type          int    `db:"type" json:"type"`
+	Pinned        bool   `db:"pinned" json:"pinned"`
+	SortOrder     int    `db:"sort_order" json:"sortOrder"`
+	ArchiveURL    string `db:"archive_url" json:"archiveUrl"`
+	LinkStatus    string `db:"link_status" json:"linkStatus"`
+	LastChecked   string `db:"last_checked" json:"lastChecked"`
+	Status        string `db:"status" json:"status"`
+	CreatedAt     string `db:"created_at" json:"createdAt"`
+	CompletedAt   string `db:"completed_at" json:"completedAt"`
+	// PaperID is "doi:<doi>" or "arxiv:<id>" for papers, when known.
+	PaperID string `db:"paper_id" json:"paperId"`
+	// ExtractionStatus tracks background metadata extraction; only complete
+	// articles, and ones being re-extracted, show up in listings.
+	ExtractionStatus string `db:"extraction_status" json:"extractionStatus"`
+	// Extracted marks an article whose extraction has completed at least
+	// once, so it stays listed with its current metadata while it is
+	// extracted again.
+	Extracted bool `db:"extracted" json:"-"`
+	// ExtractionError is why the last extraction attempt failed.
+	ExtractionError    string `db:"extraction_error" json:"extractionError"`
+	ExtractionAttempts int    `db:"extraction_attempts" json:"extractionAttempts"`
+	// ExtractionMaxAttempts overrides EXTRACT_MAX_ATTEMPTS for this article
+	// when set, from POST /articles?retries=N.
+	ExtractionMaxAttempts int `db:"extraction_max_attempts" json:"-"`
+	// Embedding is the encoded summary embedding, nil unless embeddings
+	// are enabled.
+	Embedding []byte `db:"embedding" json:"-"`
+	// Extractor names what produced the metadata, e.g. exa-contents.
+	Extractor string `db:"extractor" json:"extractor"`
+	// TypeUncertain flags a type the extractor wasn't confident about, for
+	// the user to confirm.
+	TypeUncertain bool `db:"type_uncertain" json:"typeUncertain"`
+	// AuthorGuessed marks an author taken from the link's domain rather than
+	// the page.
+	AuthorGuessed bool `db:"author_guessed" json:"authorGuessed"`
+	// TitleGuessed marks a title made from the link's path because the page
+	// gave none, for the user to fix.
+	TitleGuessed bool `db:"title_guessed" json:"titleGuessed"`
+	// SiteName is the publication the article is from: its og:site_name,
+	// or the link's domain when the page doesn't name itself.
+	SiteName string `db:"site_name" json:"siteName"`
+	// Progress is how far through the article the reader is, 0-100.
+	Progress int `db:"progress" json:"progress"`
+	// Content is the article text as markdown, kept when STORE_CONTENT is
+	// set and served by GET /articles/{id}/raw.
+	Content string `db:"content" json:"-"`
+	// SuggestedTags are the tags extraction suggested with AUTO_TAG. They
+	// are applied to the article; this keeps them apart from tags the user
+	// added so a client can offer to remove the ones it doesn't want.
+	SuggestedTags TagList `db:"suggested_tags" json:"suggestedTags"`
+	// Rating is the user's rating of the article, 1-5, nil when unrated.
+	Rating *int `db:"rating" json:"rating"`
+	// Notes are the user's own notes on the article.
+	Notes string `db:"notes" json:"notes"`
+}
+
+// ArticleChange is one recorded edit of an article, such as a PATCH, a
+// re-extraction or a resummarize, with the fields it changed.
+type ArticleChange struct {
+	ID        int    `db:"id" json:"id"`
+	ArticleID int    `db:"article_id" json:"articleId"`
+	Source    string `db:"source" json:"source"`
+	ChangedAt string `db:"changed_at" json:"changedAt"`
+	// RevertOf is the change a revert went back to before, 0 otherwise.
+	RevertOf int           `db:"revert_of" json:"revertOf,omitempty"`
+	Fields   []FieldChange `db:"-" json:"fields"`
+}
+
+// FieldChange is the old and new value of one field changed by an edit.
+type FieldChange struct {
+	ChangeID int    `db:"change_id" json:"-"`
+	Field    string `db:"field" json:"field"`
+	OldValue string `db:"old_value" json:"oldValue"`
+	NewValue string `db:"new_value" json:"newValue"`
+}
+
+// TagList is a list of tags stored as one comma-separated column.
+type TagList []string
+
+func (t TagList) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+func (t *TagList) Scan(src any) error {
+	var raw string
+	switch v := src.(type) {
+	case nil:
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into TagList", src)
+	}
+	*t = TagList{}
+	if raw != "" {
+		*t = strings.Split(raw, ",")
+	}
+	return nil
+}
+
+// TypeCount is the number of stored articles of one type.
+type TypeCount struct {
+	Type  int    `db:"type" json:"type"`
+	Label string `db:"-" json:"label"`
+	Count int    `db:"count" json:"count"`
+}
+
+// SiteCount is the number of articles saved from one site.
+type SiteCount struct {
+	SiteName string `db:"site_name" json:"siteName"`
+	Count    int    `db:"count" json:"count"`
+}
+
+// ArticleStats summarizes the library for a reading dashboard.
+type ArticleStats struct {
+	Total  int         `json:"total"`
+	ByType []TypeCount `json:"byType"`
+	// ReadThisMonth counts articles marked read since the start of the
+	// current month, UTC.
+	ReadThisMonth    int    `json:"readThisMonth"`
+	EarliestDateRead string `json:"earliestDateRead"`
+	LatestDateRead   string `json:"latestDateRead"`
+}
+
+// VelocityPoint counts the articles added and completed in one period.
+type VelocityPoint struct {
+	Period    string `db:"period" json:"period"`
+	Added     int    `db:"added" json:"added"`
+	Completed int    `db:"completed" json:"completed"`
+}
+
+// ArticleLink is an alternate URL of an article, such as the PDF or the
+// publisher's copy of a paper. The article's primary link stays on Article.
+type ArticleLink struct {
+	ID        int    `db:"id" json:"id"`
+	ArticleID int    `db:"article_id" json:"articleId"`
+	Link      string `db:"link" json:"link"`
+	Label     string `db:"label" json:"label"`
+	CreatedAt string `db:"created_at" json:"createdAt"`
+}
+
+// Goal is a target number of articles of one type to finish per period.
+type Goal struct {
+	Type   int    `db:"type" json:"type"`
+	Period string `db:"period" json:"period"`
+	Target int    `db:"target" json:"target"`
 }